@@ -0,0 +1,87 @@
+package cluster
+
+import "slices"
+
+// BKTree is a metric tree that finds the items within a given distance of a
+// query, without comparing the query to every item. The distance function
+// must be a metric, like strdist.HammingDistance or strdist.EditDistance.
+//
+// A BKTree is safe for concurrent searches, but not for adding concurrently
+// with other operations.
+type BKTree[T any] struct {
+	dist  func(a, b T) int
+	root  *bkNode[T]
+	items []T
+}
+
+// A node of a BK-tree.
+type bkNode[T any] struct {
+	id       int
+	children map[int]*bkNode[T] // By distance from this node.
+}
+
+// NewBKTree returns an empty tree with the given distance function.
+func NewBKTree[T any](dist func(a, b T) int) *BKTree[T] {
+	return &BKTree[T]{dist: dist}
+}
+
+// Add adds an item to the tree and returns its ID, which is the number of
+// items added before it.
+func (t *BKTree[T]) Add(item T) int {
+	id := len(t.items)
+	t.items = append(t.items, item)
+	if t.root == nil {
+		t.root = &bkNode[T]{id: id}
+		return id
+	}
+	node := t.root
+	for {
+		d := t.dist(item, t.items[node.id])
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = map[int]*bkNode[T]{}
+			}
+			node.children[d] = &bkNode[T]{id: id}
+			return id
+		}
+		node = child
+	}
+}
+
+// Len returns the number of items in the tree.
+func (t *BKTree[T]) Len() int {
+	return len(t.items)
+}
+
+// Item returns the item with the given ID.
+func (t *BKTree[T]) Item(id int) T {
+	return t.items[id]
+}
+
+// Search returns the IDs of the items within distance radius of query, in
+// increasing order.
+func (t *BKTree[T]) Search(query T, radius int) []int {
+	var result []int
+	if t.root == nil {
+		return nil
+	}
+	stack := []*bkNode[T]{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		d := t.dist(query, t.items[node.id])
+		if d <= radius {
+			result = append(result, node.id)
+		}
+		// By the triangle inequality, matches are only under children
+		// whose distance is within radius of d.
+		for cd, child := range node.children {
+			if cd >= d-radius && cd <= d+radius {
+				stack = append(stack, child)
+			}
+		}
+	}
+	slices.Sort(result)
+	return result
+}
@@ -0,0 +1,223 @@
+// Package cluster groups sequences that differ by sequencing errors, like UMIs
+// and cell barcodes.
+//
+// The clustering functions take sequences and their read counts, and return
+// clusters as lists of indexes into the input. The first index of each
+// cluster is its representative, which has the highest count.
+//
+//	clusters := cluster.Directional(umis, counts,
+//		strdist.HammingDistance, 1)
+//	for _, c := range clusters {
+//		fmt.Println(string(umis[c[0]]), cluster.Count(c, counts))
+//	}
+package cluster
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Distance is a distance function between sequences, like
+// strdist.HammingDistance. It must be a metric.
+type Distance func(a, b []byte) int
+
+// Returns the indexes of seqs sorted by decreasing count, with ties broken
+// by index. A nil counts slice counts every sequence once.
+func byCount(seqs [][]byte, counts []int) []int {
+	order := make([]int, len(seqs))
+	for i := range order {
+		order[i] = i
+	}
+	if counts != nil {
+		slices.SortStableFunc(order, func(a, b int) int {
+			return cmp.Compare(counts[b], counts[a])
+		})
+	}
+	return order
+}
+
+// Returns the count of sequence i.
+func countOf(counts []int, i int) int {
+	if counts == nil {
+		return 1
+	}
+	return counts[i]
+}
+
+// Returns the neighbors of each sequence within threshold, excluding
+// itself.
+func neighbors(seqs [][]byte, dist Distance, threshold int) [][]int {
+	tree := NewBKTree(dist)
+	for _, s := range seqs {
+		tree.Add(s)
+	}
+	result := make([][]int, len(seqs))
+	for i, s := range seqs {
+		for _, j := range tree.Search(s, threshold) {
+			if j != i {
+				result[i] = append(result[i], j)
+			}
+		}
+	}
+	return result
+}
+
+// Count returns the total count of the sequences in a cluster. A nil counts
+// slice counts every sequence once.
+func Count(cluster []int, counts []int) int {
+	sum := 0
+	for _, i := range cluster {
+		sum += countOf(counts, i)
+	}
+	return sum
+}
+
+// Greedy clusters sequences around centroids. The sequence with the highest
+// count becomes a centroid, and all sequences within threshold of it join
+// its cluster. This repeats with the remaining sequences. A nil counts
+// slice counts every sequence once.
+func Greedy(seqs [][]byte, counts []int, dist Distance,
+	threshold int) [][]int {
+	tree := NewBKTree(dist)
+	for _, s := range seqs {
+		tree.Add(s)
+	}
+	order := byCount(seqs, counts)
+	rank := ranks(order)
+	assigned := make([]bool, len(seqs))
+	var result [][]int
+	for _, i := range order {
+		if assigned[i] {
+			continue
+		}
+		c := []int{i}
+		assigned[i] = true
+		for _, j := range tree.Search(seqs[i], threshold) {
+			if !assigned[j] {
+				c = append(c, j)
+				assigned[j] = true
+			}
+		}
+		sortByRank(c[1:], rank)
+		result = append(result, c)
+	}
+	return result
+}
+
+// Directional clusters UMIs using the directional method of UMI-tools.
+// Sequence a absorbs sequence b if they are within threshold and
+// count(a) >= 2*count(b)-1, so that errors are absorbed by their much more
+// abundant source. A cluster is everything reachable from its
+// representative through such edges. A nil counts slice counts every
+// sequence once.
+func Directional(seqs [][]byte, counts []int, dist Distance,
+	threshold int) [][]int {
+	adj := neighbors(seqs, dist, threshold)
+	order := byCount(seqs, counts)
+	rank := ranks(order)
+	assigned := make([]bool, len(seqs))
+	var result [][]int
+	for _, i := range order {
+		if assigned[i] {
+			continue
+		}
+		c := []int{i}
+		assigned[i] = true
+		for k := 0; k < len(c); k++ {
+			a := c[k]
+			for _, b := range adj[a] {
+				if !assigned[b] &&
+					countOf(counts, a) >= 2*countOf(counts, b)-1 {
+					c = append(c, b)
+					assigned[b] = true
+				}
+			}
+		}
+		sortByRank(c[1:], rank)
+		result = append(result, c)
+	}
+	return result
+}
+
+// Adjacency clusters UMIs using the adjacency method of UMI-tools. In each
+// connected component of sequences within threshold of each other, the
+// fewest highest-count sequences whose neighborhoods cover the component
+// become representatives, and every other sequence joins the first
+// representative it neighbors. A nil counts slice counts every sequence
+// once.
+func Adjacency(seqs [][]byte, counts []int, dist Distance,
+	threshold int) [][]int {
+	adj := neighbors(seqs, dist, threshold)
+	order := byCount(seqs, counts)
+	rank := ranks(order)
+	visited := make([]bool, len(seqs))
+	var result [][]int
+	for _, i := range order {
+		if visited[i] {
+			continue
+		}
+		// Find the connected component.
+		comp := []int{i}
+		visited[i] = true
+		for k := 0; k < len(comp); k++ {
+			for _, b := range adj[comp[k]] {
+				if !visited[b] {
+					comp = append(comp, b)
+					visited[b] = true
+				}
+			}
+		}
+		sortByRank(comp, rank)
+		result = append(result, coverComponent(comp, adj, rank)...)
+	}
+	return result
+}
+
+// Splits a connected component, sorted by rank, into clusters around the
+// fewest leading sequences that cover it.
+func coverComponent(comp []int, adj [][]int, rank []int) [][]int {
+	covered := map[int]bool{}
+	leads := 0
+	for len(covered) < len(comp) {
+		lead := comp[leads]
+		covered[lead] = true
+		for _, b := range adj[lead] {
+			covered[b] = true
+		}
+		leads++
+	}
+
+	var result [][]int
+	assigned := map[int]bool{}
+	for _, lead := range comp[:leads] {
+		assigned[lead] = true
+	}
+	for _, lead := range comp[:leads] {
+		c := []int{lead}
+		for _, b := range adj[lead] {
+			if !assigned[b] {
+				c = append(c, b)
+				assigned[b] = true
+			}
+		}
+		sortByRank(c[1:], rank)
+		result = append(result, c)
+	}
+	return result
+}
+
+// Returns the position of each index in order.
+func ranks(order []int) []int {
+	result := make([]int, len(order))
+	for r, i := range order {
+		result[i] = r
+	}
+	return result
+}
+
+// Sorts indexes by their rank.
+func sortByRank(a []int, rank []int) {
+	slices.SortFunc(a, func(x, y int) int {
+		return cmp.Compare(rank[x], rank[y])
+	})
+}
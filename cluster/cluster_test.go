@@ -0,0 +1,118 @@
+package cluster
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/fluhus/golgi/strdist"
+)
+
+// Returns byte slices of the given strings.
+func toBytes(s ...string) [][]byte {
+	result := make([][]byte, len(s))
+	for i := range s {
+		result[i] = []byte(s[i])
+	}
+	return result
+}
+
+func TestBKTree(t *testing.T) {
+	words := toBytes("AAAA", "AAAT", "AATT", "ATTT", "TTTT", "CCCC", "AAAA")
+	tree := NewBKTree(strdist.HammingDistance)
+	for i, w := range words {
+		if id := tree.Add(w); id != i {
+			t.Fatalf("Add(%q)=%v, want %v", w, id, i)
+		}
+	}
+	tests := []struct {
+		query  string
+		radius int
+		want   []int
+	}{
+		{"AAAA", 0, []int{0, 6}},
+		{"AAAA", 1, []int{0, 1, 6}},
+		{"AATT", 1, []int{1, 2, 3}},
+		{"GGGG", 3, nil},
+		{"CCCG", 1, []int{5}},
+	}
+	for _, test := range tests {
+		got := tree.Search([]byte(test.query), test.radius)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Search(%q,%v)=%v, want %v",
+				test.query, test.radius, got, test.want)
+		}
+	}
+}
+
+// Compares tree searches with a linear scan.
+func TestBKTree_random(t *testing.T) {
+	tree := NewBKTree(strdist.EditDistance)
+	var words [][]byte
+	for i := 0; i < 500; i++ {
+		w := randomSeq(4 + rand.Intn(4))
+		words = append(words, w)
+		tree.Add(w)
+	}
+	for i := 0; i < 50; i++ {
+		query, radius := randomSeq(6), rand.Intn(4)
+		var want []int
+		for j, w := range words {
+			if strdist.EditDistance(query, w) <= radius {
+				want = append(want, j)
+			}
+		}
+		if got := tree.Search(query, radius); !slices.Equal(got, want) {
+			t.Fatalf("Search(%q,%v)=%v, want %v", query, radius, got, want)
+		}
+	}
+}
+
+func TestDirectional(t *testing.T) {
+	// AAAA absorbs AAAT (10 >= 2*3-1) which absorbs AATT (3 >= 2*1-1).
+	// AAAA does not absorb AAAC (10 < 2*8-1).
+	umis := toBytes("AAAT", "AAAA", "AATT", "AAAC", "GGGG")
+	counts := []int{3, 10, 1, 8, 2}
+	got := Directional(umis, counts, strdist.HammingDistance, 1)
+	want := [][]int{{1, 0, 2}, {3}, {4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Directional(%q,%v)=%v, want %v", umis, counts, got, want)
+	}
+}
+
+func TestAdjacency(t *testing.T) {
+	// AATT is covered only after AAAA, AAAC and AAAT are all leads.
+	umis := toBytes("AAAT", "AAAA", "AATT", "AAAC", "GGGG")
+	counts := []int{3, 10, 1, 8, 2}
+	got := Adjacency(umis, counts, strdist.HammingDistance, 1)
+	want := [][]int{{1}, {3}, {0, 2}, {4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Adjacency(%q,%v)=%v, want %v", umis, counts, got, want)
+	}
+}
+
+func TestGreedy(t *testing.T) {
+	seqs := toBytes("AAAT", "AAAA", "AATT", "AAAC", "GGGG")
+	counts := []int{3, 10, 1, 8, 2}
+	got := Greedy(seqs, counts, strdist.HammingDistance, 1)
+	want := [][]int{{1, 3, 0}, {4}, {2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Greedy(%q,%v)=%v, want %v", seqs, counts, got, want)
+	}
+	if got := Count(want[0], counts); got != 21 {
+		t.Errorf("Count(%v)=%v, want 21", want[0], got)
+	}
+	if got := Count(want[0], nil); got != 3 {
+		t.Errorf("Count(%v,nil)=%v, want 3", want[0], got)
+	}
+}
+
+// Returns a random DNA sequence of the given length.
+func randomSeq(n int) []byte {
+	result := make([]byte, n)
+	for i := range result {
+		result[i] = "ACGT"[rand.Intn(4)]
+	}
+	return result
+}
@@ -0,0 +1,199 @@
+// Package extsort sorts record streams that are larger than memory.
+//
+// Records are accumulated in memory up to a budget, then each batch is sorted
+// and written to a temporary file (a run). The runs are merged with a k-way
+// merge when iterating over the result. Temporary files are removed when
+// iteration ends.
+package extsort
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"iter"
+	"os"
+	"slices"
+
+	"github.com/fluhus/golgi/genome"
+	"github.com/fluhus/golgi/records"
+)
+
+// Default memory budget, in bytes.
+const defaultMaxMemory = 256 << 20
+
+// Maximal number of runs that are merged at once. More runs are merged in
+// several passes, to avoid running out of file descriptors.
+const maxOpenRuns = 256
+
+// Options for external sorting. A nil or zero value uses the defaults.
+type Options struct {
+	// Approximate number of bytes of records held in memory.
+	// Default 256MiB.
+	MaxMemory int
+
+	// Directory for temporary files. Default os.TempDir().
+	TempDir string
+
+	// Chromosome order, for the format-specific sorters, such as
+	// Genome.Less. Default genome.NaturalLess.
+	ChrLess func(a, b string) bool
+}
+
+// Returns a copy of the options with defaults filled in.
+func (o *Options) withDefaults() Options {
+	var result Options
+	if o != nil {
+		result = *o
+	}
+	if result.MaxMemory <= 0 {
+		result.MaxMemory = defaultMaxMemory
+	}
+	if result.ChrLess == nil {
+		result.ChrLess = genome.NaturalLess
+	}
+	return result
+}
+
+// Sort returns an iterator over the records of seq, ordered by less. size
+// returns the approximate memory footprint of a record in bytes, and is
+// used against the memory budget. Records of equal order keep their input
+// order. T must be encodable by encoding/gob.
+func Sort[T any](seq iter.Seq2[T, error], less func(a, b T) bool,
+	size func(T) int, opts *Options) iter.Seq2[T, error] {
+	o := opts.withDefaults()
+	cmp := func(a, b T) int {
+		if less(a, b) {
+			return -1
+		}
+		if less(b, a) {
+			return 1
+		}
+		return 0
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		var runs []string
+		defer func() {
+			for _, run := range runs {
+				os.Remove(run)
+			}
+		}()
+
+		// Read input and spill sorted runs.
+		var buf []T
+		bufSize := 0
+		for x, err := range seq {
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			buf = append(buf, x)
+			bufSize += size(x)
+			if bufSize < o.MaxMemory {
+				continue
+			}
+
+			slices.SortStableFunc(buf, cmp)
+			run, err := writeRun(records.Slice(buf), o.TempDir)
+			if run != "" {
+				runs = append(runs, run)
+			}
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			clear(buf)
+			buf = buf[:0]
+			bufSize = 0
+		}
+		slices.SortStableFunc(buf, cmp)
+
+		// Reduce the number of runs to merge at once. Merged runs go first,
+		// to keep equal records in input order.
+		for len(runs) > maxOpenRuns {
+			run, err := writeRun(mergeRuns[T](runs[:maxOpenRuns], less),
+				o.TempDir)
+			for _, old := range runs[:maxOpenRuns] {
+				os.Remove(old)
+			}
+			runs = append([]string{run}, runs[maxOpenRuns:]...)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+		}
+
+		// Merge runs with what's left in memory.
+		seqs := make([]iter.Seq2[T, error], 0, len(runs)+1)
+		for _, run := range runs {
+			seqs = append(seqs, readRun[T](run))
+		}
+		seqs = append(seqs, records.Slice(buf))
+		for x, err := range records.MergeSorted(less, seqs...) {
+			if !yield(x, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over the merged records of the given runs.
+func mergeRuns[T any](runs []string,
+	less func(a, b T) bool) iter.Seq2[T, error] {
+	seqs := make([]iter.Seq2[T, error], len(runs))
+	for i, run := range runs {
+		seqs[i] = readRun[T](run)
+	}
+	return records.MergeSorted(less, seqs...)
+}
+
+// Writes the given records to a new temporary file and returns its name.
+// The name is returned also on error, if the file was created.
+func writeRun[T any](seq iter.Seq2[T, error], dir string) (string, error) {
+	f, err := os.CreateTemp(dir, "extsort_")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	for x, err := range seq {
+		if err != nil {
+			return f.Name(), err
+		}
+		if err := enc.Encode(x); err != nil {
+			return f.Name(), err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return f.Name(), err
+	}
+	return f.Name(), f.Close()
+}
+
+// Returns an iterator over the records in the given run file.
+func readRun[T any](run string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		f, err := os.Open(run)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer f.Close()
+
+		dec := gob.NewDecoder(bufio.NewReader(f))
+		for {
+			var x T
+			err := dec.Decode(&x)
+			if err == io.EOF {
+				return
+			}
+			if !yield(x, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package extsort
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/golgi/formats/bed"
+	"github.com/fluhus/golgi/formats/sam"
+	"github.com/fluhus/golgi/genome"
+	"github.com/fluhus/golgi/records"
+)
+
+func TestSort(t *testing.T) {
+	type pair struct {
+		Key   int
+		Order int
+	}
+	rnd := rand.New(rand.NewSource(0))
+	var input []pair
+	for i := 0; i < 10000; i++ {
+		input = append(input, pair{rnd.Intn(100), i})
+	}
+	want := append([]pair(nil), input...)
+	sort.SliceStable(want, func(i, j int) bool {
+		return want[i].Key < want[j].Key
+	})
+
+	for _, maxMemory := range []int{1, 10, 1000, 0} {
+		dir := t.TempDir()
+		opts := &Options{MaxMemory: maxMemory, TempDir: dir}
+		got, err := records.Collect(Sort(records.Slice(input),
+			func(a, b pair) bool { return a.Key < b.Key },
+			func(pair) int { return 1 }, opts))
+		if err != nil {
+			t.Fatalf("Sort(MaxMemory=%v) failed: %v", maxMemory, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Sort(MaxMemory=%v) is not sorted", maxMemory)
+		}
+		if files, _ := os.ReadDir(dir); len(files) != 0 {
+			t.Fatalf("Sort(MaxMemory=%v) left %v temporary files",
+				maxMemory, len(files))
+		}
+	}
+}
+
+func TestSortBed(t *testing.T) {
+	input := "track name=foo\n" +
+		"chr10\t5\t10\ta\n" +
+		"chr2\t30\t40\tb\n" +
+		"chr2\t30\t35\tc\n" +
+		"chr1\t100\t200\td\n" +
+		"chr2\t1\t2\te\n"
+	want := []string{
+		"chr1\t100\t200\td",
+		"chr2\t1\t2\te",
+		"chr2\t30\t35\tc",
+		"chr2\t30\t40\tb",
+		"chr10\t5\t10\ta",
+	}
+	var got []string
+	opts := &Options{MaxMemory: 1, TempDir: t.TempDir()}
+	for b, err := range SortBed(bed.NewScanner(strings.NewReader(input)),
+		opts) {
+		if err != nil {
+			t.Fatalf("SortBed() failed: %v", err)
+		}
+		got = append(got, b.Text)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SortBed()=%v, want %v", got, want)
+	}
+}
+
+func TestSortSAM(t *testing.T) {
+	input := "@HD\tVN:1.6\n" +
+		"r1\t0\tchr10\t5\t30\t4M\t*\t0\t0\tAAAA\tFFFF\n" +
+		"r2\t4\t*\t0\t0\t*\t*\t0\t0\tCCCC\tFFFF\n" +
+		"r3\t0\tchr2\t50\t30\t4M\t*\t0\t0\tGGGG\tFFFF\tAS:i:4\n" +
+		"r4\t0\tchr2\t7\t30\t4M\t*\t0\t0\tTTTT\tFFFF\n"
+	r := sam.NewReader(bytes.NewBufferString(input))
+	if h, err := r.NextHeader(); err != nil || h != "@HD\tVN:1.6" {
+		t.Fatalf("NextHeader()=%q,%v, want @HD", h, err)
+	}
+	var got []string
+	opts := &Options{MaxMemory: 1, TempDir: t.TempDir()}
+	for s, err := range SortSAM(r, opts) {
+		if err != nil {
+			t.Fatalf("SortSAM() failed: %v", err)
+		}
+		got = append(got, s.Qname)
+		if s.Qname == "r3" && s.Tags["AS"] != 4 {
+			t.Errorf("SortSAM() tags of r3=%v, want AS:4", s.Tags)
+		}
+	}
+	if want := []string{"r4", "r3", "r1", "r2"}; !reflect.DeepEqual(got,
+		want) {
+		t.Fatalf("SortSAM()=%v, want %v", got, want)
+	}
+}
+
+func TestSortBed_genomeOrder(t *testing.T) {
+	input := "chr10\t5\t10\n" +
+		"chr2\t30\t40\n" +
+		"chrX\t1\t2\n" +
+		"chr1\t100\t200\n"
+	g, err := genome.ReadChromSizes(strings.NewReader(
+		"chrX\t1000\nchr10\t1000\nchr1\t1000\n"))
+	if err != nil {
+		t.Fatalf("ReadChromSizes() failed: %v", err)
+	}
+	want := []string{"chrX", "chr10", "chr1", "chr2"}
+	var got []string
+	opts := &Options{ChrLess: g.Less}
+	for b, err := range SortBed(bed.NewScanner(strings.NewReader(input)),
+		opts) {
+		if err != nil {
+			t.Fatalf("SortBed() failed: %v", err)
+		}
+		got = append(got, b.Chr)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SortBed()=%v, want %v", got, want)
+	}
+}
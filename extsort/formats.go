@@ -0,0 +1,109 @@
+package extsort
+
+// Sorting of genomic formats by coordinates.
+
+import (
+	"iter"
+
+	"github.com/fluhus/golgi/formats/bed"
+	"github.com/fluhus/golgi/formats/bed/bedgraph"
+	"github.com/fluhus/golgi/formats/sam"
+)
+
+// Approximate memory overhead of a single record, beyond its text.
+const recordOverhead = 100
+
+// Bed is a bed entry with its original line.
+type Bed struct {
+	*bed.Bed
+	Text string // The parsed line as is.
+}
+
+// BedGraph is a bed-graph entry with its original line.
+type BedGraph struct {
+	*bedgraph.BedGraph
+	Text string // The parsed line as is.
+}
+
+// SortBed returns an iterator over the remaining entries of the scanner,
+// sorted by chromosome, start and end.
+func SortBed(s *bed.Scanner, opts *Options) iter.Seq2[*Bed, error] {
+	chrLess := opts.withDefaults().ChrLess
+	less := func(a, b *Bed) bool {
+		return regionLess(chrLess, a.Chr, a.Start, a.End, b.Chr, b.Start,
+			b.End)
+	}
+	size := func(b *Bed) int {
+		return len(b.Text)*2 + recordOverhead
+	}
+	seq := func(yield func(*Bed, error) bool) {
+		for s.Scan() {
+			if !yield(&Bed{s.Bed(), s.Text()}, nil) {
+				return
+			}
+		}
+		if s.Err() != nil {
+			yield(nil, s.Err())
+		}
+	}
+	return Sort(seq, less, size, opts)
+}
+
+// SortBedGraph returns an iterator over the remaining entries of the scanner,
+// sorted by chromosome, start and end.
+func SortBedGraph(s *bedgraph.Scanner,
+	opts *Options) iter.Seq2[*BedGraph, error] {
+	chrLess := opts.withDefaults().ChrLess
+	less := func(a, b *BedGraph) bool {
+		return regionLess(chrLess, a.Chr, a.Start, a.End, b.Chr, b.Start,
+			b.End)
+	}
+	size := func(b *BedGraph) int {
+		return len(b.Text)*2 + recordOverhead
+	}
+	seq := func(yield func(*BedGraph, error) bool) {
+		for s.Scan() {
+			if !yield(&BedGraph{s.Bed(), s.Text()}, nil) {
+				return
+			}
+		}
+		if s.Err() != nil {
+			yield(nil, s.Err())
+		}
+	}
+	return Sort(seq, less, size, opts)
+}
+
+// SortSAM returns an iterator over the remaining alignments of the reader,
+// sorted by reference name and position. Unmapped reads (reference '*') come
+// last. Header lines should be read before calling this function, since they
+// are skipped otherwise.
+func SortSAM(r *sam.Reader, opts *Options) iter.Seq2[*sam.SAM, error] {
+	chrLess := opts.withDefaults().ChrLess
+	less := func(a, b *sam.SAM) bool {
+		if a.Rname != b.Rname {
+			if a.Rname == "*" || b.Rname == "*" {
+				return b.Rname == "*"
+			}
+			return chrLess(a.Rname, b.Rname)
+		}
+		return a.Pos < b.Pos
+	}
+	size := func(s *sam.SAM) int {
+		return len(s.Qname) + len(s.Cigar) + len(s.Seq) + len(s.Qual) +
+			len(s.Tags)*recordOverhead + recordOverhead
+	}
+	return Sort(r.All(), less, size, opts)
+}
+
+// Compares 2 genomic regions by chromosome, start and end.
+func regionLess(chrLess func(a, b string) bool, chr1 string, start1, end1 int,
+	chr2 string, start2, end2 int) bool {
+	if chr1 != chr2 {
+		return chrLess(chr1, chr2)
+	}
+	if start1 != start2 {
+		return start1 < start2
+	}
+	return end1 < end2
+}
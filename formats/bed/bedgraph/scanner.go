@@ -5,6 +5,7 @@ package bedgraph
 import (
 	"bufio"
 	"io"
+	"iter"
 )
 
 // Scans bed-graph entries from a stream. Ignores header if exists.
@@ -80,3 +81,19 @@ func (s *Scanner) Scan() bool {
 	s.first = false
 	return true
 }
+
+// Returns an iterator over the remaining entries. Stops after the first error,
+// which is yielded with a nil bed-graph. End of input is not yielded as an
+// error.
+func (s *Scanner) All() iter.Seq2[*BedGraph, error] {
+	return func(yield func(*BedGraph, error) bool) {
+		for s.Scan() {
+			if !yield(s.Bed(), nil) {
+				return
+			}
+		}
+		if s.Err() != nil {
+			yield(nil, s.Err())
+		}
+	}
+}
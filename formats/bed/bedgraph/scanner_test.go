@@ -59,3 +59,28 @@ func compare(b1, b2 *BedGraph) bool {
 	return b1.Chr == b1.Chr && b1.Start == b2.Start && b1.End == b2.End &&
 		b1.Value == b2.Value
 }
+
+func TestScannerAll(t *testing.T) {
+	bedString := "chr1\t10\t20\t3.14\nchr4\t50\t66\t2.7\nchr4\t50\t66\n"
+	want := []*BedGraph{{"chr1", 10, 20, 3.14}, {"chr4", 50, 66, 2.7}}
+	var got []*BedGraph
+	var gotErr error
+	for b, err := range NewScanner(strings.NewReader(bedString)).All() {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		got = append(got, b)
+	}
+	if gotErr == nil {
+		t.Fatal("Scanning succeeded, expected error")
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Scanned %v entries, expected %v", len(got), len(want))
+	}
+	for i := range got {
+		if !compare(got[i], want[i]) {
+			t.Fatal("Bad bed scanned:", got[i], "expected:", want[i])
+		}
+	}
+}
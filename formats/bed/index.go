@@ -3,8 +3,10 @@ package bed
 // Bed file indexing.
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sort"
+	"strings"
 )
 
 // ----- EVENT TYPE ------------------------------------------------------------
@@ -12,9 +14,9 @@ import (
 // Raw event for indexing. Each row in a bed file creates 2 events - one for
 // start and one for end.
 type event struct {
-	pos   int    // Position along chromosome.
-	name  string // Name of the event (4'th column).
-	start bool   // True is event is starting, of false if not.
+	pos   int   // Position along chromosome.
+	entry *item // The entry that created the event.
+	start bool  // True is event is starting, of false if not.
 }
 
 // Sorting interface.
@@ -31,76 +33,152 @@ func (a events) Less(i, j int) bool {
 	}
 
 	// End comes before start.
-	return a[i].start == false
+	return !a[i].start && a[j].start
 }
 
 func (a events) Swap(i, j int) {
 	a[i], a[j] = a[j], a[i]
 }
 
-// ----- EVENT SET/COUNTER -----------------------------------------------------
+// ----- INDEXED ENTRY ---------------------------------------------------------
 
-// Counts overlapping counts of events.
-type eventCounter map[string]int
+// A single bed entry in the index.
+type item struct {
+	id   int   // Order of addition to the builder, for deterministic results.
+	name int32 // Interned ID of the payload's name.
+	*Payload
+}
+
+// Sorting interface, by order of addition.
+type items []*item
 
-// Adds 1 to a name's count.
-func (e eventCounter) inc(name string) {
-	e[name]++
+func (a items) Len() int {
+	return len(a)
 }
 
-// Removes 1 from a name's count.
-func (e eventCounter) dec(name string) {
-	e[name]--
-	if e[name] == 0 {
-		delete(e, name)
+func (a items) Less(i, j int) bool {
+	return a[i].id < a[j].id
+}
+
+func (a items) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+// Returns the payloads of the items, in the same order.
+func (a items) payloads() []*Payload {
+	result := make([]*Payload, len(a))
+	for i := range a {
+		result[i] = a[i].Payload
 	}
+	return result
 }
 
-// Makes an event set from the given count-map. All counts are assumed to be
-// at least 1. Panics if not.
-func eventSet(counts eventCounter) map[string]struct{} {
-	set := map[string]struct{}{}
+// ----- INTERNED SETS ---------------------------------------------------------
 
-	for evt := range counts {
-		if counts[evt] < 1 {
-			panic("Got non-positive count at '" + evt + "'")
-		}
+// An immutable set of entries that overlap a tile. Equal sets are shared
+// between tiles, so that annotations with millions of tiles (RepeatMasker,
+// etc.) do not hold a separate set per tile.
+type itemSet struct {
+	items items    // Overlapping entries, by order of addition.
+	names []string // Distinct overlapping names, sorted.
+}
 
-		// Add to set.
-		set[evt] = struct{}{}
-	}
+// The empty set, shared by all indexes.
+var emptySet = &itemSet{}
 
-	return set
+// Interns strings and sets, so that equal values share the same memory.
+type interner struct {
+	names    map[string]int32    // Maps name to its ID.
+	ids      []string            // Maps ID to its name.
+	nameSets map[string][]string // Maps encoded name IDs to their set.
+	sets     map[string]*itemSet // Maps encoded entry IDs to their set.
 }
 
-// ----- INDEX -----------------------------------------------------------------
+// Returns a new empty interner.
+func newInterner() *interner {
+	return &interner{map[string]int32{}, nil, map[string][]string{},
+		map[string]*itemSet{}}
+}
 
-// A single tile in the index.
-type tile struct {
-	pos   int                 // Start position (0-based).
-	names map[string]struct{} // Set of overlapping event names.
+// Returns the ID of the given name, assigning a new one if needed.
+func (in *interner) name(name string) int32 {
+	id, ok := in.names[name]
+	if !ok {
+		id = int32(len(in.ids))
+		name = strings.Clone(name) // Don't hold the whole parsed line.
+		in.names[name] = id
+		in.ids = append(in.ids, name)
+	}
+	return id
+}
+
+// Returns the shared set that holds the given entries. Entries should be
+// sorted by order of addition.
+func (in *interner) set(a items) *itemSet {
+	if len(a) == 0 {
+		return emptySet
+	}
+
+	// Encode entry IDs as a map key.
+	key := make([]byte, 0, len(a)*binary.MaxVarintLen64)
+	for _, it := range a {
+		key = binary.AppendUvarint(key, uint64(it.id))
+	}
+	if set, ok := in.sets[string(key)]; ok {
+		return set
+	}
+
+	// Create a new set.
+	ids := make([]int32, 0, len(a))
+	for _, it := range a {
+		ids = append(ids, it.name)
+	}
+	set := &itemSet{append(items(nil), a...), in.nameSet(ids)}
+	in.sets[string(key)] = set
+
+	return set
 }
 
-// Compares a tile's values to another's. Returns true iff all fields except pos
-// have equal values. Deep-checks event sets.
-func (t *tile) valuesEqual(t2 *tile) bool {
-	if len(t.names) != len(t2.names) {
-		return false
+// Returns the shared sorted set of names for the given name IDs.
+func (in *interner) nameSet(ids []int32) []string {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// Encode distinct IDs as a map key.
+	key := make([]byte, 0, len(ids)*binary.MaxVarintLen32)
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			key = binary.AppendUvarint(key, uint64(id))
+		}
+	}
+	if set, ok := in.nameSets[string(key)]; ok {
+		return set
 	}
 
-	for e := range t.names {
-		if _, ok := t2.names[e]; !ok {
-			return false
+	// Create a new set.
+	var set []string
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			set = append(set, in.ids[id])
 		}
 	}
+	sort.Strings(set)
+	in.nameSets[string(key)] = set
 
-	return true
+	return set
+}
+
+// ----- INDEX -----------------------------------------------------------------
+
+// A single tile in the index.
+type tile struct {
+	pos int      // Start position (0-based).
+	set *itemSet // Overlapping entries, shared between equal tiles.
 }
 
 // A slice of tiles, duh.
-type tiles []*tile
+type tiles []tile
 
-// A bed index. Used to retrieve names of overlapping regions
+// A bed index. Used to retrieve names and metadata of overlapping regions
 // (genes, exons...).
 //
 // To create an index, use the IndexBuilder type.
@@ -109,54 +187,130 @@ type Index map[string]tiles
 // Appends a tile at the given chromosome name, only if it has different values
 // from the last tile in that chromosome. Tile position must be greater than
 // last tile's position.
-func (idx Index) add(chr string, t *tile) {
+func (idx Index) add(chr string, t tile) {
 	ichr := idx[chr]
 	if len(ichr) > 0 && ichr[len(ichr)-1].pos >= t.pos {
 		panic("Input tile position must be greater than last tile's.")
 	}
 
-	if len(ichr) == 0 || !ichr[len(ichr)-1].valuesEqual(t) {
+	if len(ichr) == 0 || ichr[len(ichr)-1].set != t.set {
 		idx[chr] = append(idx[chr], t)
 	}
 }
 
+// Returns the index of the tile that contains the given position, or -1 if
+// none does.
+func (idx Index) find(chr string, pos int) int {
+	ichr := idx[chr]
+	return sort.Search(len(ichr), func(j int) bool {
+		return ichr[j].pos > pos
+	}) - 1
+}
+
+// Returns the entries that overlap the range [start,end), by order of
+// addition. If end is not greater than start, looks up start alone.
+func (idx Index) itemsRange(chr string, start, end int) items {
+	if end <= start {
+		end = start + 1
+	}
+
+	ichr := idx[chr]
+	i := idx.find(chr, start)
+	if i == -1 {
+		i = 0
+	}
+
+	// Only one tile, no need to merge.
+	if i < len(ichr) && (i == len(ichr)-1 || ichr[i+1].pos >= end) {
+		if ichr[i].pos >= end {
+			return nil
+		}
+		return ichr[i].set.items
+	}
+
+	var result items
+	seen := map[*item]struct{}{}
+	for ; i < len(ichr) && ichr[i].pos < end; i++ {
+		for _, it := range ichr[i].set.items {
+			if _, ok := seen[it]; !ok {
+				seen[it] = struct{}{}
+				result = append(result, it)
+			}
+		}
+	}
+	sort.Sort(result)
+
+	return result
+}
+
 // Returns a set of overlapping names at the given position. Modifying the set
 // does not affect the index. Always returns non-nil.
 func (idx Index) Names(chr string, pos int) map[string]struct{} {
 	result := map[string]struct{}{}
-	ichr := idx[chr]
-
-	// If no data, return empty.
-	if len(ichr) == 0 {
-		return result
-	}
 
 	// Search for containing tile.
-	i := sort.Search(len(ichr), func(j int) bool {
-		return ichr[j].pos > pos
-	}) - 1
+	i := idx.find(chr, pos)
 
 	// Not found.
 	if i == -1 {
 		return result
 	}
 
-	for name := range ichr[i].names {
+	for _, name := range idx[chr][i].set.names {
 		result[name] = struct{}{}
 	}
 
 	return result
 }
 
-// Returns the name at the given position. If several overlap, returns one
-// arbitrarily. If non found, returns an empty string.
+// Returns a set of names that overlap the range [start,end). Modifying the set
+// does not affect the index. Always returns non-nil.
+func (idx Index) NamesRange(chr string, start, end int) map[string]struct{} {
+	result := map[string]struct{}{}
+	for _, it := range idx.itemsRange(chr, start, end) {
+		result[it.Name] = struct{}{}
+	}
+	return result
+}
+
+// Returns the name at the given position. If several overlap, returns the one
+// that was added to the builder first. If non found, returns an empty string.
 func (idx Index) Name(chr string, pos int) string {
-	for name := range idx.Names(chr, pos) {
-		return name
+	if p := idx.Resolve(chr, pos, pos+1, nil); p != nil {
+		return p.Name
 	}
 	return ""
 }
 
+// Returns the payloads that overlap the given position, by order of addition
+// to the builder. Modifying the slice does not affect the index.
+func (idx Index) Payloads(chr string, pos int) []*Payload {
+	i := idx.find(chr, pos)
+	if i == -1 {
+		return nil
+	}
+	return idx[chr][i].set.items.payloads()
+}
+
+// Returns the payloads that overlap the range [start,end), by order of addition
+// to the builder. Modifying the slice does not affect the index.
+func (idx Index) PayloadsRange(chr string, start, end int) []*Payload {
+	return idx.itemsRange(chr, start, end).payloads()
+}
+
+// Returns the payload that overlaps the range [start,end) and is preferred by
+// the given priority. Ties are broken by order of addition to the builder,
+// and a nil priority picks the first added. Returns nil if none overlap.
+func (idx Index) Resolve(chr string, start, end int, prior Priority) *Payload {
+	var result *Payload
+	for _, it := range idx.itemsRange(chr, start, end) {
+		if result == nil || (prior != nil && prior(it.Payload, result)) {
+			result = it.Payload
+		}
+	}
+	return result
+}
+
 // A string representation, for debugging.
 func (idx Index) str() string {
 	result := ""
@@ -164,8 +318,8 @@ func (idx Index) str() string {
 		result += chr + "\n"
 		for _, t := range idx[chr] {
 			result += fmt.Sprintf("\t%d\t[", t.pos)
-			for name := range t.names {
-				result += name + ", "
+			for _, it := range t.set.items {
+				result += it.Name + ", "
 			}
 			result += "]\n"
 		}
@@ -175,55 +329,112 @@ func (idx Index) str() string {
 
 // ----- INDEX BUILDER ---------------------------------------------------------
 
-// Creates indexes from given bed entries.
-type IndexBuilder map[string]events // Maps chromosome to list of events.
+// Creates indexes from given bed entries. Create with NewIndexBuilder.
+// Copies of a builder share its entries.
+type IndexBuilder struct {
+	*builderState
+}
+
+// The entries of an index builder.
+type builderState struct {
+	events map[string]events // Maps chromosome to list of events.
+	named  map[string]*item  // Shared entries of name-only additions.
+	in     *interner         // Interns names and sets.
+	n      int               // Number of entries added so far.
+}
 
 // Returns a new index builder.
 func NewIndexBuilder() IndexBuilder {
-	return IndexBuilder{}
+	return IndexBuilder{&builderState{map[string]events{},
+		map[string]*item{}, newInterner(), 0}}
+}
+
+// Panics if the builder was not created by NewIndexBuilder.
+func (b IndexBuilder) check() {
+	if b.builderState == nil {
+		panic("IndexBuilder should be created with NewIndexBuilder")
+	}
 }
 
-// Adds a bed entry to the builder.
+// Adds a bed entry to the builder. Entries with equal names share a single
+// payload, whose order of addition is that of the first of them.
 func (b IndexBuilder) Add(chr string, start, end int, name string) {
-	b[chr] = append(b[chr], &event{start, name, true},
-		&event{end, name, false})
+	b.check()
+	it := b.named[name]
+	if it == nil {
+		it = b.newItem(&Payload{Name: name, Strand: '.'})
+		b.named[name] = it
+	}
+	b.add(chr, start, end, it)
+}
+
+// Adds a bed entry with its metadata to the builder. Lookups return the given
+// payload as is. Empty entries (end <= start) are ignored.
+func (b IndexBuilder) AddPayload(chr string, start, end int, p *Payload) {
+	b.check()
+	b.add(chr, start, end, b.newItem(p))
+}
+
+// Creates a new entry for the given payload.
+func (b *builderState) newItem(p *Payload) *item {
+	b.n++
+	return &item{b.n - 1, b.in.name(p.Name), p}
+}
+
+// Adds the start and end events of an entry.
+func (b *builderState) add(chr string, start, end int, it *item) {
+	if end <= start {
+		return
+	}
+	b.events[chr] = append(b.events[chr], &event{start, it, true},
+		&event{end, it, false})
 }
 
 // Builds an index out of the builder. Builder keeps its state and can be used
 // again with more entries, keeping what it had before.
 func (b IndexBuilder) Build() Index {
+	b.check()
 	result := Index{}
 
-	for chr, bchr := range b {
+	for chr, bchr := range b.events {
 		// Sort events.
 		sort.Sort(bchr)
 
 		// Create tiles.
 		result[chr] = tiles{}
-		counts := eventCounter{}
+		active := map[*item]int{} // Counts, since named entries may overlap.
 		for i := range bchr {
 			// Create new tile if needed.
 			if i > 0 && bchr[i].pos != bchr[i-1].pos {
-				set := eventSet(counts)
-				t := &tile{bchr[i-1].pos, set}
-				result.add(chr, t)
+				result.add(chr, tile{bchr[i-1].pos, b.set(active)})
 			}
 
-			// Update counter.
+			// Update active entries.
 			if bchr[i].start {
-				counts.inc(bchr[i].name)
+				active[bchr[i].entry]++
 			} else {
-				counts.dec(bchr[i].name)
+				active[bchr[i].entry]--
+				if active[bchr[i].entry] == 0 {
+					delete(active, bchr[i].entry)
+				}
 			}
 		}
 
 		// Create tile for last events (doesn't happen in the above loop).
 		if len(bchr) > 0 {
-			set := eventSet(counts)
-			t := &tile{bchr[len(bchr)-1].pos, set}
-			result.add(chr, t)
+			result.add(chr, tile{bchr[len(bchr)-1].pos, b.set(active)})
 		}
 	}
 
 	return result
 }
+
+// Returns the shared set of the given active entries.
+func (b *builderState) set(active map[*item]int) *itemSet {
+	a := make(items, 0, len(active))
+	for it := range active {
+		a = append(a, it)
+	}
+	sort.Sort(a)
+	return b.in.set(a)
+}
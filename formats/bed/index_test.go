@@ -1,7 +1,10 @@
 package bed
 
 import (
+	"fmt"
+	"math/rand"
 	"reflect"
+	"runtime"
 	"testing"
 )
 
@@ -30,6 +33,31 @@ func TestIndex_simple(t *testing.T) {
 	}
 }
 
+func TestIndexBuilder_copies(t *testing.T) {
+	b := NewIndexBuilder()
+	b.Add("chr1", 0, 10, "1")
+	c := b // Copies share entries.
+	c.Add("chr1", 5, 15, "2")
+	NewIndexBuilder().Add("chr1", 0, 1, "x") // Callable on a value.
+	idx := b.Build()
+
+	tests := []struct {
+		pos  int
+		want []string
+	}{
+		{0, []string{"1"}},
+		{7, []string{"1", "2"}},
+		{12, []string{"2"}},
+	}
+	for _, test := range tests {
+		if n := idx.Names("chr1", test.pos); !reflect.DeepEqual(n,
+			names(test.want)) {
+			t.Errorf("Names(chr1,%v)=%v, want %v", test.pos, n,
+				names(test.want))
+		}
+	}
+}
+
 func TestIndex_complex(t *testing.T) {
 	b := NewIndexBuilder()
 	b.Add("chr1", 0, 10, "1")
@@ -82,3 +110,200 @@ func names(n []string) map[string]struct{} {
 	}
 	return result
 }
+
+func TestIndex_payloads(t *testing.T) {
+	p1 := &Payload{Name: "exon", Score: 5}
+	p2 := &Payload{Name: "intron", Score: 8}
+	p3 := &Payload{Name: "exon", Score: 1}
+	b := NewIndexBuilder()
+	b.AddPayload("chr1", 0, 10, p1)
+	b.AddPayload("chr1", 5, 20, p2)
+	b.AddPayload("chr1", 15, 30, p3)
+	idx := b.Build()
+
+	tests := []struct {
+		chr   string
+		start int
+		end   int
+		want  []*Payload
+	}{
+		{"chr1", 0, 1, []*Payload{p1}},
+		{"chr1", 5, 6, []*Payload{p1, p2}},
+		{"chr1", 10, 15, []*Payload{p2}},
+		{"chr1", 0, 30, []*Payload{p1, p2, p3}},
+		{"chr1", 12, 16, []*Payload{p2, p3}},
+		{"chr1", 30, 40, []*Payload{}},
+		{"chr2", 0, 40, []*Payload{}},
+	}
+
+	for _, test := range tests {
+		got := idx.PayloadsRange(test.chr, test.start, test.end)
+		if len(got) != 0 || len(test.want) != 0 {
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("PayloadsRange(%v,%v,%v)=%v, want %v", test.chr,
+					test.start, test.end, got, test.want)
+			}
+		}
+		if test.end-test.start == 1 {
+			got := idx.Payloads(test.chr, test.start)
+			if len(got) != 0 || len(test.want) != 0 {
+				if !reflect.DeepEqual(got, test.want) {
+					t.Errorf("Payloads(%v,%v)=%v, want %v", test.chr,
+						test.start, got, test.want)
+				}
+			}
+		}
+	}
+
+	if got, want := idx.NamesRange("chr1", 12, 16),
+		names([]string{"intron", "exon"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("NamesRange(chr1,12,16)=%v, want %v", got, want)
+	}
+}
+
+func TestIndex_resolve(t *testing.T) {
+	p1 := &Payload{Name: "intron", Score: 5}
+	p2 := &Payload{Name: "exon", Score: 8}
+	p3 := &Payload{Name: "promoter", Score: 1}
+	b := NewIndexBuilder()
+	b.AddPayload("chr1", 0, 10, p1)
+	b.AddPayload("chr1", 5, 20, p2)
+	b.AddPayload("chr1", 15, 30, p3)
+	idx := b.Build()
+
+	tests := []struct {
+		start int
+		end   int
+		prior Priority
+		want  *Payload
+	}{
+		{0, 30, nil, p1},
+		{0, 30, ByNames("exon", "intron"), p2},
+		{0, 30, ByNames("promoter"), p3},
+		{0, 30, ByNames("cpg_island"), p1},
+		{0, 10, ByNames("promoter"), p1},
+		{0, 30, ByScore(), p2},
+		{16, 30, ByScore(), p2},
+		{20, 30, ByScore(), p3},
+		{30, 40, ByScore(), nil},
+	}
+
+	for _, test := range tests {
+		if got := idx.Resolve("chr1", test.start, test.end,
+			test.prior); got != test.want {
+			t.Errorf("Resolve(chr1,%v,%v)=%v, want %v",
+				test.start, test.end, got, test.want)
+		}
+	}
+
+	if got := idx.Name("chr1", 7); got != "intron" {
+		t.Errorf("Name(chr1,7)=%q, want %q", got, "intron")
+	}
+}
+
+func TestParsePayload(t *testing.T) {
+	b := &Bed{"chr1", 10, 20}
+	tests := []struct {
+		fields []string
+		want   *Payload
+	}{
+		{nil, &Payload{"", 0, '.', b, nil}},
+		{[]string{"a"}, &Payload{"a", 0, '.', b, []string{"a"}}},
+		{[]string{"a", "3.5", "-"},
+			&Payload{"a", 3.5, '-', b, []string{"a", "3.5", "-"}}},
+		{[]string{"a", ".", "+", "x"},
+			&Payload{"a", 0, '+', b, []string{"a", ".", "+", "x"}}},
+	}
+	for _, test := range tests {
+		got, err := ParsePayload(b, test.fields)
+		if err != nil {
+			t.Fatalf("ParsePayload(%v) failed: %v", test.fields, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParsePayload(%v)=%v, want %v", test.fields, got, test.want)
+		}
+	}
+
+	bad := [][]string{{"a", "x"}, {"a", "1", "*"}}
+	for _, fields := range bad {
+		if _, err := ParsePayload(b, fields); err == nil {
+			t.Errorf("ParsePayload(%v) succeeded, want error", fields)
+		}
+	}
+}
+
+func TestIndex_sharedSets(t *testing.T) {
+	b := NewIndexBuilder()
+	b.Add("chr1", 0, 10, "L1")
+	b.Add("chr1", 20, 30, "L1")
+	b.Add("chr2", 0, 10, "L1")
+	b.Add("chr2", 5, 15, "Alu")
+	b.Add("chr2", 20, 30, "Alu")
+	b.Add("chr2", 25, 35, "L1")
+	b.AddPayload("chr3", 0, 10, &Payload{Name: "L1"})
+	b.AddPayload("chr3", 20, 30, &Payload{Name: "L1"})
+	idx := b.Build()
+
+	if idx["chr1"][0].set != idx["chr1"][2].set {
+		t.Errorf("equal sets on chr1 are not shared")
+	}
+	if idx["chr1"][0].set != idx["chr2"][0].set {
+		t.Errorf("equal sets across chromosomes are not shared")
+	}
+	if idx["chr2"][1].set != idx["chr2"][5].set {
+		t.Errorf("equal sets with several names are not shared")
+	}
+	if got, want := idx["chr3"][0].set.names,
+		idx["chr3"][2].set.names; &got[0] != &want[0] {
+		t.Errorf("equal name sets are not shared: %v, %v", got, want)
+	}
+	if got, want := idx.Names("chr2", 27),
+		names([]string{"L1", "Alu"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names(chr2,27)=%v, want %v", got, want)
+	}
+}
+
+// Builds an index the size of a whole-genome RepeatMasker annotation and
+// reports the memory it holds.
+func BenchmarkIndex_repeatMasker(b *testing.B) {
+	const (
+		numChrs    = 24
+		chrLength  = 130000000
+		numRepeats = 5500000
+		numNames   = 1500
+		maxLength  = 600
+	)
+
+	rnd := rand.New(rand.NewSource(0))
+	chrs := make([]string, numChrs)
+	for i := range chrs {
+		chrs[i] = fmt.Sprint("chr", i+1)
+	}
+	repeatNames := make([]string, numNames)
+	for i := range repeatNames {
+		repeatNames[i] = fmt.Sprint("repeat", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		before := heapAlloc()
+		builder := NewIndexBuilder()
+		for j := 0; j < numRepeats; j++ {
+			start := rnd.Intn(chrLength)
+			builder.Add(chrs[rnd.Intn(numChrs)], start,
+				start+1+rnd.Intn(maxLength), repeatNames[rnd.Intn(numNames)])
+		}
+		idx := builder.Build()
+		builder = IndexBuilder{}
+		b.ReportMetric(float64(heapAlloc()-before)/(1<<20), "MiB/index")
+		runtime.KeepAlive(idx)
+	}
+}
+
+// Returns the number of heap bytes in use, after garbage collection.
+func heapAlloc() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
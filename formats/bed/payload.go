@@ -0,0 +1,80 @@
+package bed
+
+// Metadata attached to indexed bed entries.
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Payload is the metadata of a single bed entry in an index.
+type Payload struct {
+	Name   string   // Name of the entry (4'th column).
+	Score  float64  // Score of the entry (5'th column).
+	Strand byte     // Strand of the entry (6'th column), '+', '-' or '.'.
+	Bed    *Bed     // The original bed entry.
+	Fields []string // Extra fields of the original bed line.
+}
+
+// Creates a payload from a parsed bed line. Name, score and strand are taken
+// from the extra fields where available. A score of '.' is treated as 0.
+// Returns a non-nil error if the score or strand could not be parsed.
+func ParsePayload(b *Bed, fields []string) (*Payload, error) {
+	result := &Payload{Strand: '.', Bed: b, Fields: fields}
+
+	if len(fields) > 0 {
+		result.Name = fields[0]
+	}
+
+	if len(fields) > 1 && fields[1] != "." {
+		var err error
+		result.Score, err = strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad score: %q", fields[1])
+		}
+	}
+
+	if len(fields) > 2 {
+		if fields[2] != "+" && fields[2] != "-" && fields[2] != "." {
+			return nil, fmt.Errorf("Bad strand: %q, expected '+', '-' or '.'",
+				fields[2])
+		}
+		result.Strand = fields[2][0]
+	}
+
+	return result, nil
+}
+
+// ----- PRIORITY --------------------------------------------------------------
+
+// Decides which payload wins when several overlap. Returns true iff a should
+// be preferred over b.
+type Priority func(a, b *Payload) bool
+
+// Returns a priority that prefers payloads by the order of the given names.
+// Names that are not in the list come after all listed names.
+func ByNames(names ...string) Priority {
+	rank := map[string]int{}
+	for i := len(names) - 1; i >= 0; i-- {
+		rank[names[i]] = i
+	}
+
+	return func(a, b *Payload) bool {
+		ra, ok := rank[a.Name]
+		if !ok {
+			ra = len(names)
+		}
+		rb, ok := rank[b.Name]
+		if !ok {
+			rb = len(names)
+		}
+		return ra < rb
+	}
+}
+
+// Returns a priority that prefers payloads with higher scores.
+func ByScore() Priority {
+	return func(a, b *Payload) bool {
+		return a.Score > b.Score
+	}
+}
@@ -5,6 +5,7 @@ package bed
 import (
 	"bufio"
 	"io"
+	"iter"
 )
 
 // Scans bed entries from a stream. Ignores header if exists.
@@ -80,3 +81,18 @@ func (s *Scanner) Scan() bool {
 	s.first = false
 	return true
 }
+
+// Returns an iterator over the remaining entries. Stops after the first error,
+// which is yielded with a nil bed. End of input is not yielded as an error.
+func (s *Scanner) All() iter.Seq2[*Bed, error] {
+	return func(yield func(*Bed, error) bool) {
+		for s.Scan() {
+			if !yield(s.Bed(), nil) {
+				return
+			}
+		}
+		if s.Err() != nil {
+			yield(nil, s.Err())
+		}
+	}
+}
@@ -57,3 +57,23 @@ func TestScannerWithHeader(t *testing.T) {
 func compare(b1, b2 *Bed) bool {
 	return b1.Chr == b1.Chr && b1.Start == b2.Start && b1.End == b2.End
 }
+
+func TestScannerAll(t *testing.T) {
+	bedString := "header\nchr1\t10\t20\nchr4\t50\t66\n"
+	want := []*Bed{{"chr1", 10, 20}, {"chr4", 50, 66}}
+	var got []*Bed
+	for b, err := range NewScanner(strings.NewReader(bedString)).All() {
+		if err != nil {
+			t.Fatal("Scanning failed. Error:", err)
+		}
+		got = append(got, b)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Scanned %v entries, expected %v", len(got), len(want))
+	}
+	for i := range got {
+		if !compare(got[i], want[i]) {
+			t.Fatal("Bad bed scanned:", got[i], "expected:", want[i])
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package fasta
+
+// Fasta index (.fai) files, as created by samtools faidx.
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FaiEntry is a single sequence in a fasta index.
+type FaiEntry struct {
+	Name      string // Sequence name, up to the first whitespace
+	Length    int    // Number of bases
+	Offset    int64  // Byte offset of the first base in the file
+	LineBases int    // Number of bases in each line
+	LineWidth int    // Number of bytes in each line, including the newline
+}
+
+// ReadFai reads a fasta index from a .fai stream.
+func ReadFai(r io.Reader) ([]*FaiEntry, error) {
+	var result []*FaiEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if scanner.Text() == "" {
+			continue
+		}
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("line %d: bad number of fields: %d, "+
+				"expected at least 5", line, len(fields))
+		}
+		e := &FaiEntry{Name: fields[0]}
+		var nums [4]int64
+		for i := range nums {
+			var err error
+			nums[i], err = strconv.ParseInt(fields[i+1], 10, 64)
+			if err != nil || nums[i] < 0 {
+				return nil, fmt.Errorf("line %d: bad number: %q", line,
+					fields[i+1])
+			}
+		}
+		e.Length, e.Offset = int(nums[0]), nums[1]
+		e.LineBases, e.LineWidth = int(nums[2]), int(nums[3])
+		if e.Length > 0 && (e.LineBases == 0 || e.LineWidth < e.LineBases) {
+			return nil, fmt.Errorf("line %d: bad line lengths: %d, %d", line,
+				e.LineBases, e.LineWidth)
+		}
+		result = append(result, e)
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+	return result, nil
+}
+
+// WriteFai writes a fasta index in .fai format.
+func WriteFai(w io.Writer, entries []*FaiEntry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		_, err := fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", e.Name, e.Length,
+			e.Offset, e.LineBases, e.LineWidth)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// MakeFai creates an index of a fasta stream. All lines of a sequence must
+// have the same length, except the last one.
+func MakeFai(r io.Reader) ([]*FaiEntry, error) {
+	br := bufio.NewReader(r)
+	var result []*FaiEntry
+	var e *FaiEntry
+	var pos int64
+	short := false // Last line was shorter than the ones before it.
+	for line := 1; ; line++ {
+		text, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(text) == 0 {
+			break
+		}
+		width := len(text)
+		bases := len(bytes.TrimRight(text, "\r\n"))
+
+		switch {
+		case text[0] == '>':
+			name, _, _ := strings.Cut(string(text[1:bases]), " ")
+			name, _, _ = strings.Cut(name, "\t")
+			e = &FaiEntry{Name: name, Offset: pos + int64(width)}
+			result = append(result, e)
+			short = false
+		case e == nil:
+			return nil, fmt.Errorf("line %d: sequence before first name",
+				line)
+		case bases == 0:
+			short = true
+		default:
+			if short || (e.LineBases != 0 && bases > e.LineBases) {
+				return nil, fmt.Errorf("line %d: uneven line lengths in "+
+					"sequence %q", line, e.Name)
+			}
+			if e.LineBases == 0 {
+				e.LineBases, e.LineWidth = bases, width
+			}
+			short = bases != e.LineBases || width != e.LineWidth
+			e.Length += bases
+		}
+
+		pos += int64(width)
+		if err == io.EOF {
+			break
+		}
+	}
+	return result, nil
+}
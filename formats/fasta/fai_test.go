@@ -0,0 +1,156 @@
+package fasta
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A fasta file with uneven sequence lengths, for index tests.
+const faiTestInput = ">chr1 human\nAACCG\nGTTAC\nGG\n>chr2\nacgt\r\nac\r\n" +
+	">empty\n>chr3\tx\nTTT"
+
+func TestMakeFai(t *testing.T) {
+	want := []*FaiEntry{
+		{"chr1", 12, 12, 5, 6},
+		{"chr2", 6, 33, 4, 6},
+		{"empty", 0, 50, 0, 0},
+		{"chr3", 3, 58, 3, 3},
+	}
+	got, err := MakeFai(strings.NewReader(faiTestInput))
+	if err != nil {
+		t.Fatalf("MakeFai(%q) failed: %v", faiTestInput, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MakeFai(%q)=%v, want %v", faiTestInput, got, want)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteFai(buf, got); err != nil {
+		t.Fatalf("WriteFai() failed: %v", err)
+	}
+	got, err = ReadFai(buf)
+	if err != nil {
+		t.Fatalf("ReadFai() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadFai(WriteFai())=%v, want %v", got, want)
+	}
+}
+
+func TestMakeFai_bad(t *testing.T) {
+	inputs := []string{
+		"AAA\n",
+		">a\nAA\nAAA\n",
+		">a\nAAA\nA\nAAA\n",
+		">a\nAAA\n\nAAA\n",
+		">a\nAAA\r\nAAA\nA\n",
+	}
+	for _, input := range inputs {
+		if got, err := MakeFai(strings.NewReader(input)); err == nil {
+			t.Errorf("MakeFai(%q)=%v, want error", input, got)
+		}
+	}
+}
+
+func TestReadFai_bad(t *testing.T) {
+	inputs := []string{
+		"a\t1\t2\t3\n",
+		"a\t1\t2\t3\tx\n",
+		"a\t-1\t2\t3\t4\n",
+		"a\t5\t2\t3\t2\n",
+	}
+	for _, input := range inputs {
+		if got, err := ReadFai(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadFai(%q)=%v, want error", input, got)
+		}
+	}
+}
+
+func TestIndexedFasta(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.fa")
+	if err := os.WriteFile(file, []byte(faiTestInput), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenIndexed(file)
+	if err != nil {
+		t.Fatalf("OpenIndexed(%q) failed: %v", file, err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       string
+	}{
+		{"chr1", 0, 12, "AACCGGTTACGG"},
+		{"chr1", 3, 8, "CGGTT"},
+		{"chr1", 5, 5, ""},
+		{"chr1", 10, 12, "GG"},
+		{"chr2", 2, 6, "gtac"},
+		{"chr3", 1, 3, "TT"},
+		{"empty", 0, 0, ""},
+	}
+	for _, test := range tests {
+		got, err := f.Subsequence(test.name, test.start, test.end)
+		if err != nil {
+			t.Errorf("Subsequence(%q,%v,%v) failed: %v", test.name,
+				test.start, test.end, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("Subsequence(%q,%v,%v)=%q, want %q", test.name,
+				test.start, test.end, got, test.want)
+		}
+	}
+
+	bad := []struct {
+		name       string
+		start, end int
+	}{
+		{"chr4", 0, 1}, {"chr1", -1, 2}, {"chr1", 3, 2}, {"chr1", 0, 13},
+	}
+	for _, test := range bad {
+		if got, err := f.Subsequence(test.name, test.start,
+			test.end); err == nil {
+			t.Errorf("Subsequence(%q,%v,%v)=%q, want error", test.name,
+				test.start, test.end, got)
+		}
+	}
+
+	if n, ok := f.Length("chr2"); n != 6 || !ok {
+		t.Errorf("Length(chr2)=%v,%v, want 6,true", n, ok)
+	}
+}
+
+func TestOpenIndexed_faiFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.fa")
+	if err := os.WriteFile(file, []byte(faiTestInput), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fai := "chr1\t12\t12\t5\t6\n"
+	if err := os.WriteFile(file+".fai", []byte(fai), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenIndexed(file)
+	if err != nil {
+		t.Fatalf("OpenIndexed(%q) failed: %v", file, err)
+	}
+	defer f.Close()
+	if len(f.Entries()) != 1 {
+		t.Errorf("Entries()=%v, want 1 entry from the .fai file", f.Entries())
+	}
+
+	fai = "chr1\t100\t12\t5\t6\n"
+	if err := os.WriteFile(file+".fai", []byte(fai), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenIndexed(file); err == nil {
+		t.Errorf("OpenIndexed(%q) with a bad index succeeded, want error",
+			file)
+	}
+}
@@ -20,6 +20,7 @@ package fasta
 import (
 	"bufio"
 	"io"
+	"iter"
 )
 
 // Fasta is a single sequence in a fasta file.
@@ -117,3 +118,19 @@ loop:
 
 	return result, nil
 }
+
+// All returns an iterator over the remaining sequences. Stops after the first
+// error, which is yielded with a nil sequence. EOF is not yielded.
+func (r *Reader) All() iter.Seq2[*Fasta, error] {
+	return func(yield func(*Fasta, error) bool) {
+		for {
+			x, err := r.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(x, err) || err != nil {
+				return
+			}
+		}
+	}
+}
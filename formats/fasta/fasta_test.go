@@ -66,3 +66,21 @@ func TestNext_multiple(t *testing.T) {
 		t.Fatalf("ForEach(%q)=%v, want %v", input, got, want)
 	}
 }
+
+func TestAll(t *testing.T) {
+	input := ">foo\nAaTt\nGG\n>bar\naaaG"
+	want := []*Fasta{
+		{[]byte("foo"), []byte("AaTtGG")},
+		{[]byte("bar"), []byte("aaaG")},
+	}
+	var got []*Fasta
+	for fa, err := range NewReader(strings.NewReader(input)).All() {
+		if err != nil {
+			t.Fatalf("All(%q) failed: %v", input, err)
+		}
+		got = append(got, fa)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("All(%q)=%v, want %v", input, got, want)
+	}
+}
@@ -0,0 +1,463 @@
+package main
+
+// Versioned HTTP API.
+//
+// GET /v1/sequence?region=chr1:101-200&strand=-
+// GET /v1/sequence?chr=chr1&start=100&end=200
+//	Returns a single sequence. Regions are 1-based and inclusive, like in
+//	samtools and the UCSC browser. start and end are 0-based half-open, like
+//	in bed files.
+//
+// POST /v1/sequence
+//	Returns several sequences. The body is a JSON object:
+//	{"regions": [{"region": "chr1:101-200", "strand": "-"}, ...]}
+//
+// GET /v1/meta
+//	Returns chromosome names and lengths.
+//
+// GET /v1/assemblies
+//	Returns the names of the served assemblies. The first is the default.
+//
+// Sequence and meta requests use the default assembly. A specific assembly is
+// selected by its name, like /v1/hg38/sequence or /v1/hg38/meta.
+//
+// GET /healthz
+//	Returns 200 if the server is ready to serve.
+//
+// GET /metrics
+//	Returns request counts and latencies in Prometheus text format.
+//
+// Responses are JSON, or FASTA (text/x-fasta) for sequences and chrom sizes
+// (text/plain) for metadata, according to the Accept header or the format
+// parameter. Errors have a 4xx/5xx status and a JSON body:
+// {"error": {"code": 404, "message": "..."}}
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fluhus/golgi/sequtil"
+)
+
+// Maximal number of regions in a single batch request.
+const maxBatchRegions = 10000
+
+// Maximal size of a batch request body, in bytes.
+const maxBatchBody = 10 << 20
+
+// Line width of FASTA output.
+const fastaLineWidth = 60
+
+// Response formats.
+const (
+	formatJSON  = "json"
+	formatFasta = "fasta"
+	formatText  = "text"
+)
+
+// An error with an HTTP status code.
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// Returns a new API error with the given status code.
+func errorf(code int, format string, a ...interface{}) *apiError {
+	return &apiError{code, fmt.Sprintf(format, a...)}
+}
+
+// Writes an error response.
+func writeError(w http.ResponseWriter, err *apiError) {
+	reportf("Error %d: %s\n", err.Code, err.Message)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.Code)
+	json.NewEncoder(w).Encode(map[string]*apiError{"error": err})
+}
+
+// Writes a JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Picks a response format from the format parameter or the Accept header.
+// alt is the non-JSON format that the endpoint supports.
+func negotiate(req *http.Request, alt string) (string, *apiError) {
+	if f := req.FormValue("format"); f != "" {
+		if f != formatJSON && f != alt {
+			return "", errorf(http.StatusBadRequest,
+				"unsupported format: %q, want %q or %q", f, formatJSON, alt)
+		}
+		return f, nil
+	}
+
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return formatJSON, nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		typ, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch typ {
+		case "application/json", "application/*", "*/*":
+			return formatJSON, nil
+		case "text/x-fasta", "text/*":
+			if alt == formatFasta {
+				return formatFasta, nil
+			}
+			if typ == "text/*" {
+				return formatText, nil
+			}
+		case "text/plain":
+			return alt, nil
+		}
+	}
+	return "", errorf(http.StatusNotAcceptable,
+		"cannot produce any of: %s", accept)
+}
+
+// ----- REGIONS ---------------------------------------------------------------
+
+// A requested genomic region, 0-based half-open.
+type region struct {
+	chr    string
+	start  int
+	end    int
+	strand byte
+	name   string // Whole region string, if it may be a chromosome name.
+}
+
+// Returns the region in 1-based inclusive notation, with strand.
+func (r *region) String() string {
+	return fmt.Sprintf("%s:%d-%d(%c)", r.chr, r.start+1, r.end, r.strand)
+}
+
+// Parses a region string, like "chr1:101-200" (1-based inclusive), "chr1:101"
+// (a single base) or "chr1" (the whole chromosome). An end just before the
+// start, like "chr1:101-100", is an empty region. Commas in numbers are
+// ignored. Chromosome names may contain colons, like "HLA-A*01:01:01:01";
+// coordinates are after the last colon, and if they don't look like
+// numbers the whole string is the name.
+func parseRegion(s string, strand string) (*region, *apiError) {
+	r := &region{start: 0, end: -1}
+	var err *apiError
+	r.strand, err = parseStrand(strand)
+	if err != nil {
+		return nil, err
+	}
+
+	chr, coords := s, ""
+	if i := strings.LastIndex(s, ":"); i != -1 && isCoords(s[i+1:]) {
+		chr, coords = s[:i], s[i+1:]
+		r.name = s // Coordinates may be part of the name.
+	}
+	if chr == "" {
+		return nil, errorf(http.StatusBadRequest, "empty chromosome name in "+
+			"region: %q", s)
+	}
+	r.chr = chr
+	if coords == "" {
+		return r, nil
+	}
+
+	coords = strings.ReplaceAll(coords, ",", "")
+	startS, endS, hasEnd := strings.Cut(coords, "-")
+	start, e := strconv.Atoi(startS)
+	if e != nil || start < 1 {
+		return nil, errorf(http.StatusBadRequest, "bad start position in "+
+			"region: %q", s)
+	}
+	end := start
+	if hasEnd {
+		end, e = strconv.Atoi(endS)
+		if e != nil || end < start-1 { // start-1 is an empty region.
+			return nil, errorf(http.StatusBadRequest, "bad end position in "+
+				"region: %q", s)
+		}
+	}
+	r.start, r.end = start-1, end
+	return r, nil
+}
+
+// Returns true if s has only digits, commas and dashes, so it is meant as
+// coordinates.
+func isCoords(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789,-") == ""
+}
+
+// Parses a strand parameter. Empty means '+'.
+func parseStrand(s string) (byte, *apiError) {
+	switch s {
+	case "", "+":
+		return '+', nil
+	case "-":
+		return '-', nil
+	default:
+		return 0, errorf(http.StatusBadRequest, "bad strand: %q, want "+
+			"'+' or '-'", s)
+	}
+}
+
+// Parses a region from the parameters of a GET request.
+func regionFromRequest(req *http.Request) (*region, *apiError) {
+	if s := req.FormValue("region"); s != "" {
+		return parseRegion(s, req.FormValue("strand"))
+	}
+
+	chr := req.FormValue("chr")
+	if chr == "" {
+		return nil, errorf(http.StatusBadRequest, "missing region or chr "+
+			"parameter")
+	}
+	strand, err := parseStrand(req.FormValue("strand"))
+	if err != nil {
+		return nil, err
+	}
+	r := &region{chr: chr, start: 0, end: -1, strand: strand}
+	if s := req.FormValue("start"); s != "" {
+		var e error
+		r.start, e = strconv.Atoi(s)
+		if e != nil {
+			return nil, errorf(http.StatusBadRequest, "bad start position: "+
+				"%q", s)
+		}
+	}
+	if s := req.FormValue("end"); s != "" {
+		var e error
+		r.end, e = strconv.Atoi(s)
+		if e != nil {
+			return nil, errorf(http.StatusBadRequest, "bad end position: %q",
+				s)
+		}
+	}
+	return r, nil
+}
+
+// A fetched sequence, as returned in JSON.
+type sequenceResult struct {
+	Region   string `json:"region"`
+	Chr      string `json:"chr"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Strand   string `json:"strand"`
+	Sequence string `json:"sequence"`
+}
+
+// Fetches the sequence of the given region. An end of -1 means the end of
+// the chromosome.
+func fetch(a *assembly, r *region) (*sequenceResult, *apiError) {
+	if r.name != "" {
+		if name, ok := a.gen.Canonical(r.name); ok {
+			// A chromosome name that ends like coordinates.
+			r.chr, r.start, r.end = name, 0, -1
+		}
+	}
+	name, ok := a.gen.Canonical(r.chr)
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "no such chromosome: %q",
+			r.chr)
+	}
+	r.chr = name
+	if r.end == -1 {
+		r.end, _ = a.gen.Length(name)
+	}
+	if err := a.gen.Validate(name, r.start, r.end); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	seq, e := a.seqs.Subsequence(name, r.start, r.end)
+	if e != nil {
+		return nil, errorf(http.StatusInternalServerError, "%v", e)
+	}
+	if r.strand == '-' {
+		rc := make([]byte, len(seq))
+		if e := sequtil.ReverseComplementIUPAC(rc, seq); e != nil {
+			return nil, errorf(http.StatusUnprocessableEntity,
+				"cannot reverse-complement %v: %v", r, e)
+		}
+		seq = rc
+	}
+
+	return &sequenceResult{r.String(), r.chr, r.start, r.end,
+		string(r.strand), string(seq)}, nil
+}
+
+// ----- HANDLERS --------------------------------------------------------------
+
+// Handles sequence requests, single (GET) or batch (POST).
+func v1SequenceHandler(w http.ResponseWriter, req *http.Request,
+	a *assembly) {
+	report("Got v1 sequence request.")
+
+	format, err := negotiate(req, formatFasta)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var results []*sequenceResult
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		r, err := regionFromRequest(req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		result, err := fetch(a, r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		results = append(results, result)
+
+	case http.MethodPost:
+		results, err = fetchBatch(w, req, a)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		writeError(w, errorf(http.StatusMethodNotAllowed,
+			"method not allowed: %s", req.Method))
+		return
+	}
+
+	if format == formatFasta {
+		w.Header().Set("Content-Type", "text/x-fasta")
+		for _, r := range results {
+			writeFasta(w, r)
+		}
+		return
+	}
+	if req.Method == http.MethodPost {
+		writeJSON(w, map[string]interface{}{"sequences": results})
+	} else {
+		writeJSON(w, results[0])
+	}
+}
+
+// Body of a batch sequence request.
+type batchRequest struct {
+	Regions []struct {
+		Region string `json:"region"`
+		Strand string `json:"strand"`
+	} `json:"regions"`
+}
+
+// Fetches the regions in the body of a batch request.
+func fetchBatch(w http.ResponseWriter, req *http.Request, a *assembly) (
+	[]*sequenceResult, *apiError) {
+	var body batchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBatchBody))
+	if e := dec.Decode(&body); e != nil {
+		if _, ok := e.(*http.MaxBytesError); ok {
+			return nil, errorf(http.StatusRequestEntityTooLarge,
+				"request body is larger than %d bytes", maxBatchBody)
+		}
+		return nil, errorf(http.StatusBadRequest, "bad request body: %v", e)
+	}
+	if len(body.Regions) > maxBatchRegions {
+		return nil, errorf(http.StatusRequestEntityTooLarge,
+			"too many regions: %d, max %d", len(body.Regions),
+			maxBatchRegions)
+	}
+
+	results := make([]*sequenceResult, 0, len(body.Regions))
+	for i, r := range body.Regions {
+		reg, err := parseRegion(r.Region, r.Strand)
+		if err == nil {
+			var result *sequenceResult
+			result, err = fetch(a, reg)
+			results = append(results, result)
+		}
+		if err != nil {
+			err.Message = fmt.Sprintf("region #%d: %s", i+1, err.Message)
+			return nil, err
+		}
+	}
+	reportf("Batch of %d regions.\n", len(results))
+	return results, nil
+}
+
+// Writes a sequence in FASTA format.
+func writeFasta(w http.ResponseWriter, r *sequenceResult) {
+	fmt.Fprintf(w, ">%s\n", r.Region)
+	for i := 0; i < len(r.Sequence); i += fastaLineWidth {
+		end := min(i+fastaLineWidth, len(r.Sequence))
+		fmt.Fprintln(w, r.Sequence[i:end])
+	}
+}
+
+// A chromosome in metadata responses.
+type chromResult struct {
+	Name   string `json:"name"`
+	Length int    `json:"length"`
+}
+
+// Handles metadata requests.
+func v1MetaHandler(w http.ResponseWriter, req *http.Request, a *assembly) {
+	report("Got v1 meta request.")
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, errorf(http.StatusMethodNotAllowed,
+			"method not allowed: %s", req.Method))
+		return
+	}
+	format, err := negotiate(req, formatText)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format == formatText {
+		w.Header().Set("Content-Type", "text/plain")
+		for _, c := range a.gen.Chroms() {
+			fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Length)
+		}
+		return
+	}
+
+	var chroms []chromResult
+	for _, c := range a.gen.Chroms() {
+		chroms = append(chroms, chromResult{c.Name, c.Length})
+	}
+	writeJSON(w, map[string]interface{}{"chromosomes": chroms})
+}
+
+// An assembly in assembly list responses.
+type assemblyResult struct {
+	Name        string `json:"name"`
+	Chromosomes int    `json:"chromosomes"`
+}
+
+// Handles assembly list requests.
+func v1AssembliesHandler(w http.ResponseWriter, req *http.Request) {
+	report("Got v1 assemblies request.")
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, errorf(http.StatusMethodNotAllowed,
+			"method not allowed: %s", req.Method))
+		return
+	}
+
+	var result []assemblyResult
+	for _, a := range assemblies.all() {
+		result = append(result, assemblyResult{a.name,
+			len(a.gen.Chroms())})
+	}
+	writeJSON(w, map[string]interface{}{"assemblies": result})
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/golgi/formats/fasta"
+)
+
+// Handles requests in tests.
+var testMux *http.ServeMux
+
+// Sets up the served assemblies for tests. The default assembly is test1,
+// followed by test2.
+func setUpTest(t *testing.T) {
+	args.verbose = new(bool)
+	assemblies = &registry{byName: map[string]*assembly{}}
+	assemblies.set(testAssembly(t, "test1", []*fasta.Fasta{
+		{Name: []byte("chr1"), Sequence: []byte("AACCGGTTAC")},
+		{Name: []byte("chrM"), Sequence: []byte("ggccaRtt")},
+	}))
+	assemblies.set(testAssembly(t, "test2", []*fasta.Fasta{
+		{Name: []byte("chr1"), Sequence: []byte("TTT*")},
+	}))
+	testMux = http.NewServeMux()
+	registerHandlers(testMux)
+}
+
+// Returns an assembly with the given sequences.
+func testAssembly(t *testing.T, name string, fa []*fasta.Fasta) *assembly {
+	gen, err := genomeOf(fa)
+	if err != nil {
+		t.Fatalf("genomeOf() failed: %v", err)
+	}
+	return &assembly{name: name, seqs: newMemSequences(fa), gen: gen}
+}
+
+// Sends a request to the test server and returns the status code and body.
+func do(method, url, accept, body string) (int, string) {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	testMux.ServeHTTP(w, req)
+	b, _ := io.ReadAll(w.Result().Body)
+	return w.Code, string(b)
+}
+
+func TestV1Sequence(t *testing.T) {
+	setUpTest(t)
+	tests := []struct {
+		url  string
+		want sequenceResult
+	}{
+		{"/v1/sequence?region=chr1:3-6",
+			sequenceResult{"chr1:3-6(+)", "chr1", 2, 6, "+", "CCGG"}},
+		{"/v1/sequence?region=1:1-1,0",
+			sequenceResult{"chr1:1-10(+)", "chr1", 0, 10, "+", "AACCGGTTAC"}},
+		{"/v1/sequence?region=chr1:1-4&strand=-",
+			sequenceResult{"chr1:1-4(-)", "chr1", 0, 4, "-", "GGTT"}},
+		{"/v1/sequence?region=chr1:10",
+			sequenceResult{"chr1:10-10(+)", "chr1", 9, 10, "+", "C"}},
+		{"/v1/sequence?region=MT",
+			sequenceResult{"chrM:1-8(+)", "chrM", 0, 8, "+", "ggccaRtt"}},
+		{"/v1/sequence?region=MT&strand=-",
+			sequenceResult{"chrM:1-8(-)", "chrM", 0, 8, "-", "aaYtggcc"}},
+		{"/v1/sequence?chr=chr1&start=8&end=10",
+			sequenceResult{"chr1:9-10(+)", "chr1", 8, 10, "+", "AC"}},
+		{"/v1/sequence?region=chr1:3-2",
+			sequenceResult{"chr1:3-2(+)", "chr1", 2, 2, "+", ""}},
+		{"/v1/sequence?chr=chr1&start=2&end=2",
+			sequenceResult{"chr1:3-2(+)", "chr1", 2, 2, "+", ""}},
+	}
+	for _, test := range tests {
+		code, body := do("GET", test.url, "", "")
+		if code != http.StatusOK {
+			t.Fatalf("GET %s status=%v, want 200: %s", test.url, code, body)
+		}
+		var got sequenceResult
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatalf("GET %s returned bad JSON: %v", test.url, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GET %s=%v, want %v", test.url, got, test.want)
+		}
+	}
+}
+
+func TestV1Sequence_colons(t *testing.T) {
+	setUpTest(t)
+	assemblies.set(testAssembly(t, "test3", []*fasta.Fasta{
+		{Name: []byte("HLA-A*01:01:01:01"), Sequence: []byte("ACGT")},
+		{Name: []byte("chrUn:x"), Sequence: []byte("TTGCA")},
+	}))
+	tests := []struct {
+		region string
+		want   sequenceResult
+	}{
+		{"HLA-A*01:01:01:01", sequenceResult{"HLA-A*01:01:01:01:1-4(+)",
+			"HLA-A*01:01:01:01", 0, 4, "+", "ACGT"}},
+		{"HLA-A*01:01:01:01:2-3", sequenceResult{"HLA-A*01:01:01:01:2-3(+)",
+			"HLA-A*01:01:01:01", 1, 3, "+", "CG"}},
+		{"chrUn:x", sequenceResult{"chrUn:x:1-5(+)", "chrUn:x", 0, 5, "+",
+			"TTGCA"}},
+		{"chrUn:x:3", sequenceResult{"chrUn:x:3-3(+)", "chrUn:x", 2, 3, "+",
+			"G"}},
+	}
+	for _, test := range tests {
+		path := "/v1/test3/sequence?region=" + url.QueryEscape(test.region)
+		code, body := do("GET", path, "", "")
+		if code != http.StatusOK {
+			t.Fatalf("GET %s status=%v, want 200: %s", path, code, body)
+		}
+		var got sequenceResult
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatalf("GET %s returned bad JSON: %v", path, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GET %s=%v, want %v", path, got, test.want)
+		}
+	}
+}
+
+func TestV1Sequence_errors(t *testing.T) {
+	setUpTest(t)
+	tests := []struct {
+		method string
+		url    string
+		accept string
+		want   int
+	}{
+		{"GET", "/v1/sequence", "", http.StatusBadRequest},
+		{"GET", "/v1/sequence?region=chr2:1-2", "", http.StatusNotFound},
+		{"GET", "/v1/sequence?region=chr1:0-2", "", http.StatusBadRequest},
+		{"GET", "/v1/sequence?region=chr1:5-2", "", http.StatusBadRequest},
+		{"GET", "/v1/sequence?region=chr1:5-11", "", http.StatusBadRequest},
+		{"GET", "/v1/sequence?region=chr1:5-6&strand=x", "",
+			http.StatusBadRequest},
+		{"GET", "/v1/test2/sequence?region=chr1&strand=-", "",
+			http.StatusUnprocessableEntity},
+		{"GET", "/v1/sequence?region=chr1", "image/png",
+			http.StatusNotAcceptable},
+		{"GET", "/v1/sequence?region=chr1&format=xml", "",
+			http.StatusBadRequest},
+		{"DELETE", "/v1/sequence?region=chr1", "",
+			http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		code, body := do(test.method, test.url, test.accept, "")
+		if code != test.want {
+			t.Errorf("%s %s status=%v, want %v", test.method, test.url, code,
+				test.want)
+		}
+		var got struct{ Error apiError }
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatalf("%s %s returned bad JSON: %v", test.method, test.url,
+				err)
+		}
+		if got.Error.Code != test.want || got.Error.Message == "" {
+			t.Errorf("%s %s error=%v, want code %v", test.method, test.url,
+				got.Error, test.want)
+		}
+	}
+}
+
+func TestV1Sequence_fasta(t *testing.T) {
+	setUpTest(t)
+	tests := []struct {
+		url    string
+		accept string
+	}{
+		{"/v1/sequence?region=chr1:2-3", "text/x-fasta"},
+		{"/v1/sequence?region=chr1:2-3", "text/plain;q=0.9, */*;q=0.1"},
+		{"/v1/sequence?region=chr1:2-3&format=fasta", ""},
+	}
+	for _, test := range tests {
+		code, body := do("GET", test.url, test.accept, "")
+		if code != http.StatusOK {
+			t.Fatalf("GET %s status=%v, want 200", test.url, code)
+		}
+		if want := ">chr1:2-3(+)\nAC\n"; body != want {
+			t.Errorf("GET %s=%q, want %q", test.url, body, want)
+		}
+	}
+}
+
+func TestV1Sequence_batch(t *testing.T) {
+	setUpTest(t)
+	body := `{"regions": [{"region": "chr1:1-2"}, ` +
+		`{"region": "chr1:1-4", "strand": "-"}]}`
+	code, got := do("POST", "/v1/sequence", "text/x-fasta", body)
+	if code != http.StatusOK {
+		t.Fatalf("POST status=%v, want 200: %s", code, got)
+	}
+	if want := ">chr1:1-2(+)\nAA\n>chr1:1-4(-)\nGGTT\n"; got != want {
+		t.Errorf("POST=%q, want %q", got, want)
+	}
+
+	code, got = do("POST", "/v1/sequence", "", body)
+	if code != http.StatusOK {
+		t.Fatalf("POST status=%v, want 200: %s", code, got)
+	}
+	var result struct{ Sequences []sequenceResult }
+	if err := json.Unmarshal([]byte(got), &result); err != nil {
+		t.Fatalf("POST returned bad JSON: %v", err)
+	}
+	if len(result.Sequences) != 2 || result.Sequences[1].Sequence != "GGTT" {
+		t.Errorf("POST=%v, want 2 sequences", result.Sequences)
+	}
+
+	bad := []string{`{"regions": [{"region": "chr1:1-2"}, {"region": "x"}]}`,
+		`{"regions": `}
+	for _, body := range bad {
+		code, _ := do("POST", "/v1/sequence", "", body)
+		if code != http.StatusBadRequest && code != http.StatusNotFound {
+			t.Errorf("POST %q status=%v, want 4xx", body, code)
+		}
+	}
+}
+
+func TestV1Meta(t *testing.T) {
+	setUpTest(t)
+	code, got := do("GET", "/v1/meta", "text/plain", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET status=%v, want 200", code)
+	}
+	if want := "chr1\t10\nchrM\t8\n"; got != want {
+		t.Errorf("GET=%q, want %q", got, want)
+	}
+
+	code, got = do("GET", "/v1/meta", "application/json", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET status=%v, want 200", code)
+	}
+	want := `{"chromosomes":[{"name":"chr1","length":10},` +
+		`{"name":"chrM","length":8}]}` + "\n"
+	if got != want {
+		t.Errorf("GET=%q, want %q", got, want)
+	}
+}
+
+func TestLegacySequence(t *testing.T) {
+	setUpTest(t)
+	code, got := do("GET", "/sequence?chr=chr1&start=2&length=3", "", "")
+	if code != http.StatusOK || got != "CCG" {
+		t.Errorf("GET=%v,%q, want 200,CCG", code, got)
+	}
+	code, _ = do("GET", "/sequence?chr=chr9&start=2&length=3", "", "")
+	if code != http.StatusNotFound {
+		t.Errorf("GET status=%v, want 404", code)
+	}
+}
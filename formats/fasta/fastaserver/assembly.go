@@ -0,0 +1,326 @@
+package main
+
+// Handles loading, routing and reloading of genome assemblies.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fluhus/golgi/formats/fasta"
+	"github.com/fluhus/golgi/genome"
+)
+
+// A loaded genome assembly. Assemblies are immutable once loaded; reloading
+// replaces the whole object, so requests that already hold one are not
+// affected. Sequences are closed when the last holder releases them.
+type assembly struct {
+	name    string         // Name for routing, like hg38.
+	file    string         // Path of the fasta file.
+	modTime time.Time      // Modification time of the file when loaded.
+	seqs    sequences      // Chromosome sequences.
+	gen     *genome.Genome // Chromosome names and lengths.
+	closer  io.Closer      // Closes the sequences, may be nil.
+	refs    atomic.Int64   // Number of holders: the registry and requests.
+}
+
+// Adds a holder of the assembly.
+func (a *assembly) acquire() {
+	a.refs.Add(1)
+}
+
+// Removes a holder of the assembly, closing its sequences if it was the
+// last.
+func (a *assembly) release() {
+	if a.refs.Add(-1) == 0 && a.closer != nil {
+		if err := a.closer.Close(); err != nil {
+			fmt.Printf("Error closing %s: %v\n", a.name, err)
+		}
+	}
+}
+
+// Loads an assembly from the given fasta file. The file is memory-mapped
+// using its index (file.fai), or indexed on load if there is no index. Files
+// that cannot be indexed are read to memory.
+func loadAssembly(name, file string) (*assembly, error) {
+	stat, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := fasta.OpenIndexed(file)
+	if err != nil {
+		fmt.Printf("Cannot index %s, reading to memory: %v\n", file, err)
+		return loadAssemblyToMemory(name, file, stat.ModTime())
+	}
+	chroms := make([]genome.Chrom, len(idx.Entries()))
+	for i, e := range idx.Entries() {
+		chroms[i] = genome.Chrom{Name: e.Name, Length: e.Length}
+	}
+	gen, err := genome.New(chroms)
+	if err != nil {
+		idx.Close()
+		return nil, err
+	}
+	return &assembly{name: name, file: file, modTime: stat.ModTime(),
+		seqs: idx, gen: gen, closer: idx}, nil
+}
+
+// Loads an assembly by reading the given fasta file to memory.
+func loadAssemblyToMemory(name, file string, modTime time.Time) (*assembly,
+	error) {
+	fa, err := readFastaFile(file)
+	if err != nil {
+		return nil, err
+	}
+	gen, err := genomeOf(fa)
+	if err != nil {
+		return nil, err
+	}
+	return &assembly{name: name, file: file, modTime: modTime,
+		seqs: newMemSequences(fa), gen: gen}, nil
+}
+
+// A source of chromosome sequences.
+type sequences interface {
+	// Returns the bases of the given chromosome in [start,end).
+	Subsequence(name string, start, end int) ([]byte, error)
+}
+
+// Sequences held in memory, by chromosome name.
+type memSequences map[string][]byte
+
+// Returns sequences of the given fasta entries.
+func newMemSequences(fa []*fasta.Fasta) memSequences {
+	result := make(memSequences, len(fa))
+	for _, e := range fa {
+		result[string(e.Name)] = e.Sequence
+	}
+	return result
+}
+
+func (m memSequences) Subsequence(name string, start, end int) ([]byte,
+	error) {
+	seq, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("no such sequence: %q", name)
+	}
+	if start < 0 || end < start || end > len(seq) {
+		return nil, fmt.Errorf("bad range for %q (length %d): %d-%d", name,
+			len(seq), start, end)
+	}
+	return seq[start:end:end], nil
+}
+
+// ----- REGISTRY --------------------------------------------------------------
+
+// Holds the served assemblies by name.
+type registry struct {
+	mu     sync.RWMutex
+	byName map[string]*assembly
+	names  []string // In order of configuration. The first is the default.
+}
+
+// All served assemblies.
+var assemblies = &registry{byName: map[string]*assembly{}}
+
+// Returns the assembly with the given name, or the default assembly if name
+// is empty. Returns nil if not found. The caller should release the
+// returned assembly when done with it.
+func (r *registry) get(name string) *assembly {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name == "" {
+		if len(r.names) == 0 {
+			return nil
+		}
+		name = r.names[0]
+	}
+	a := r.byName[name]
+	if a != nil {
+		a.acquire()
+	}
+	return a
+}
+
+// Adds or replaces an assembly. A replaced assembly is closed once the
+// requests that hold it release it.
+func (r *registry) set(a *assembly) {
+	a.acquire()
+	r.mu.Lock()
+	old, ok := r.byName[a.name]
+	if !ok {
+		r.names = append(r.names, a.name)
+	}
+	r.byName[a.name] = a
+	r.mu.Unlock()
+	if old != nil {
+		old.release()
+	}
+}
+
+// Returns all assemblies in order of configuration.
+func (r *registry) all() []*assembly {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*assembly, len(r.names))
+	for i, name := range r.names {
+		result[i] = r.byName[name]
+	}
+	return result
+}
+
+// Reloads assemblies from their files. If force is false, reloads only those
+// whose file was modified since loaded. Failed reloads keep the old assembly.
+func (r *registry) reload(force bool) {
+	for _, a := range r.all() {
+		if !force {
+			stat, err := os.Stat(a.file)
+			if err != nil || stat.ModTime().Equal(a.modTime) {
+				continue
+			}
+		}
+		fmt.Printf("Reloading %s from %s...\n", a.name, a.file)
+		now := time.Now()
+		b, err := loadAssembly(a.name, a.file)
+		if err != nil {
+			fmt.Printf("Error reloading %s: %v\n", a.name, err)
+			continue
+		}
+		r.set(b)
+		reportf("Took %v.\n", time.Since(now))
+	}
+}
+
+// Reloads modified assemblies every interval, forever.
+func (r *registry) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		r.reload(false)
+	}
+}
+
+// ----- CONFIGURATION ---------------------------------------------------------
+
+// An assembly name and fasta file.
+type assemblyConfig struct {
+	name string
+	file string
+}
+
+// Reads a configuration file. Each line has an assembly name and a fasta
+// file path, separated by whitespace. Empty lines and lines starting with
+// '#' are ignored. Relative paths are relative to the configuration file.
+func readConfig(file string) ([]assemblyConfig, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseConfig(f, filepath.Dir(file))
+}
+
+// Parses a configuration stream. Relative paths are joined to dir.
+func parseConfig(r io.Reader, dir string) ([]assemblyConfig, error) {
+	var result []assemblyConfig
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: bad number of fields: %d, "+
+				"expected 2", line, len(fields))
+		}
+		name, path := fields[0], fields[1]
+		if err := checkAssemblyName(name); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("line %d: duplicate assembly: %q", line,
+				name)
+		}
+		seen[name] = true
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		result = append(result, assemblyConfig{name, path})
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no assemblies in configuration")
+	}
+	return result, nil
+}
+
+// Returns an error if the given name cannot be used for routing.
+func checkAssemblyName(name string) error {
+	if name == "v1" || name == "sequence" || name == "meta" {
+		return fmt.Errorf("reserved assembly name: %q", name)
+	}
+	if strings.ContainsAny(name, "/?#%") {
+		return fmt.Errorf("bad assembly name: %q", name)
+	}
+	return nil
+}
+
+// Returns an assembly name for a fasta file, by its base name without
+// extensions. For example: /data/hg38.fa.gz -> hg38.
+func assemblyName(file string) string {
+	name, _, _ := strings.Cut(filepath.Base(file), ".")
+	return name
+}
+
+// ----- ROUTING ---------------------------------------------------------------
+
+// A handler that serves a specific assembly.
+type assemblyHandler func(w http.ResponseWriter, req *http.Request,
+	a *assembly)
+
+// Returns a handler that resolves the assembly from the path and calls h.
+// Paths without an assembly use the default one.
+func withAssembly(h assemblyHandler, v1 bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := req.PathValue("assembly")
+		a := assemblies.get(name)
+		if a == nil {
+			if v1 {
+				writeError(w, errorf(http.StatusNotFound,
+					"no such assembly: %q", name))
+			} else {
+				legacyError(w, http.StatusNotFound,
+					"No such assembly: '%s'.", name)
+			}
+			return
+		}
+		defer a.release()
+		h(w, req, a)
+	}
+}
+
+// Registers all handlers on the given mux.
+func registerHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/sequence", withAssembly(sequenceHandler, false))
+	mux.HandleFunc("/meta", withAssembly(metaHandler, false))
+	mux.HandleFunc("/{assembly}/sequence", withAssembly(sequenceHandler,
+		false))
+	mux.HandleFunc("/{assembly}/meta", withAssembly(metaHandler, false))
+	mux.HandleFunc("/v1/sequence", withAssembly(v1SequenceHandler, true))
+	mux.HandleFunc("/v1/meta", withAssembly(v1MetaHandler, true))
+	mux.HandleFunc("/v1/{assembly}/sequence", withAssembly(v1SequenceHandler,
+		true))
+	mux.HandleFunc("/v1/{assembly}/meta", withAssembly(v1MetaHandler, true))
+	mux.HandleFunc("/v1/assemblies", v1AssembliesHandler)
+	mux.HandleFunc("/metrics", metricsHandler)
+	mux.HandleFunc("/healthz", healthHandler)
+}
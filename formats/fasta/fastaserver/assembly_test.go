@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluhus/golgi/formats/fasta"
+)
+
+func TestRouting(t *testing.T) {
+	setUpTest(t)
+	tests := []struct {
+		url  string
+		code int
+		want string
+	}{
+		{"/sequence?chr=chr1&start=0&length=3", 200, "AAC"},
+		{"/test1/sequence?chr=chr1&start=0&length=3", 200, "AAC"},
+		{"/test2/sequence?chr=chr1&start=0&length=3", 200, "TTT"},
+		{"/test2/meta", 200, "chr1: 4\n"},
+		{"/v1/test2/sequence?region=chr1:2-3&format=fasta", 200,
+			">chr1:2-3(+)\nTT\n"},
+		{"/v1/test2/meta?format=text", 200, "chr1\t4\n"},
+		{"/v1/test1/meta?format=text", 200, "chr1\t10\nchrM\t8\n"},
+		{"/test2/sequence?chr=chrM&start=0&length=3", 404, ""},
+		{"/test3/sequence?chr=chr1&start=0&length=3", 404, ""},
+		{"/v1/test3/meta", 404, ""},
+	}
+	for _, test := range tests {
+		code, got := do("GET", test.url, "", "")
+		if code != test.code {
+			t.Errorf("GET %s status=%v, want %v", test.url, code, test.code)
+			continue
+		}
+		if test.want != "" && got != test.want {
+			t.Errorf("GET %s=%q, want %q", test.url, got, test.want)
+		}
+	}
+}
+
+func TestV1Assemblies(t *testing.T) {
+	setUpTest(t)
+	code, body := do("GET", "/v1/assemblies", "", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET status=%v, want 200", code)
+	}
+	var got struct{ Assemblies []assemblyResult }
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("GET returned bad JSON: %v", err)
+	}
+	want := []assemblyResult{{"test1", 2}, {"test2", 1}}
+	if !reflect.DeepEqual(got.Assemblies, want) {
+		t.Errorf("GET=%v, want %v", got.Assemblies, want)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	input := "# Assemblies\nhg38 /data/hg38.fa\n\n  mm10\tmm10.fa  \n"
+	want := []assemblyConfig{{"hg38", "/data/hg38.fa"},
+		{"mm10", filepath.Join("conf", "mm10.fa")}}
+	got, err := parseConfig(strings.NewReader(input), "conf")
+	if err != nil {
+		t.Fatalf("parseConfig(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseConfig(%q)=%v, want %v", input, got, want)
+	}
+
+	bad := []string{"", "# Nothing\n", "hg38\n", "hg38 a.fa b.fa\n",
+		"v1 a.fa\n", "a/b a.fa\n", "hg38 a.fa\nhg38 b.fa\n"}
+	for _, input := range bad {
+		if got, err := parseConfig(strings.NewReader(input), ""); err == nil {
+			t.Errorf("parseConfig(%q)=%v, want error", input, got)
+		}
+	}
+}
+
+func TestAssemblyName(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"hg38.fa", "hg38"},
+		{"/data/hg38.fa.gz", "hg38"},
+		{"genome", "genome"},
+	}
+	for _, test := range tests {
+		if got := assemblyName(test.file); got != test.want {
+			t.Errorf("assemblyName(%q)=%q, want %q", test.file, got, test.want)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	setUpTest(t)
+	file := filepath.Join(t.TempDir(), "test3.fa")
+	if err := os.WriteFile(file, []byte(">chr1\nACGT\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := loadAssembly("test3", file)
+	if err != nil {
+		t.Fatalf("loadAssembly(%q) failed: %v", file, err)
+	}
+	assemblies.set(a)
+	old := assemblies.get("test3") // Held like by an in-flight request.
+	defer old.release()
+
+	// Unmodified files are not reloaded.
+	assemblies.reload(false)
+	if got := peek("test3"); got != old {
+		t.Fatalf("reload(false) replaced an unmodified assembly")
+	}
+
+	// Files are replaced rather than modified, since they are memory-mapped.
+	tmp := filepath.Join(filepath.Dir(file), "tmp.fa")
+	if err := os.WriteFile(tmp, []byte(">chr1\nGGGGGG\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := old.modTime.Add(time.Second)
+	if err := os.Chtimes(tmp, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatal(err)
+	}
+	assemblies.reload(false)
+	code, got := do("GET", "/test3/sequence?chr=chr1&start=0&length=6", "", "")
+	if code != http.StatusOK || got != "GGGGGG" {
+		t.Errorf("GET after reload=%v,%q, want 200,GGGGGG", code, got)
+	}
+	if seq, _ := old.seqs.Subsequence("chr1", 0, 4); string(seq) != "ACGT" {
+		t.Errorf("old assembly sequence=%q, want ACGT", seq)
+	}
+
+	// Failed reloads keep the old assembly.
+	current := peek("test3")
+	if err := os.WriteFile(tmp, []byte("bad"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatal(err)
+	}
+	assemblies.reload(true)
+	if got := peek("test3"); got != current {
+		t.Errorf("failed reload replaced the assembly")
+	}
+}
+
+// Returns the assembly with the given name, without holding it.
+func peek(name string) *assembly {
+	a := assemblies.get(name)
+	if a != nil {
+		a.release()
+	}
+	return a
+}
+
+// Counts calls to Close.
+type countCloser int
+
+func (c *countCloser) Close() error {
+	*c++
+	return nil
+}
+
+func TestAssembly_release(t *testing.T) {
+	setUpTest(t)
+	var closed countCloser
+	a := testAssembly(t, "test3", []*fasta.Fasta{
+		{Name: []byte("chr1"), Sequence: []byte("ACGT")},
+	})
+	a.closer = &closed
+	assemblies.set(a)
+
+	held := assemblies.get("test3")
+	assemblies.set(testAssembly(t, "test3", []*fasta.Fasta{
+		{Name: []byte("chr1"), Sequence: []byte("GGGG")},
+	}))
+	if closed != 0 {
+		t.Fatalf("replaced assembly was closed while held")
+	}
+	if seq, _ := held.seqs.Subsequence("chr1", 0, 4); string(seq) != "ACGT" {
+		t.Errorf("held assembly sequence=%q, want ACGT", seq)
+	}
+	held.release()
+	if closed != 1 {
+		t.Errorf("replaced assembly closed %d times after release, want 1",
+			closed)
+	}
+
+	// Requests release the assembly they used.
+	code, _ := do("GET", "/v1/test3/sequence?region=chr1", "", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET status=%v, want 200", code)
+	}
+	if got := peek("test3").refs.Load(); got != 1 {
+		t.Errorf("refs after request=%v, want 1", got)
+	}
+}
+
+func BenchmarkSequence(b *testing.B) {
+	args.verbose = new(bool)
+	file := filepath.Join(b.TempDir(), "bench.fa")
+	writeBenchmarkFasta(b, file, 24, 1000000)
+
+	indexed, err := loadAssembly("indexed", file)
+	if err != nil {
+		b.Fatal(err)
+	}
+	memory, err := loadAssemblyToMemory("memory", file, time.Time{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	assemblies = &registry{byName: map[string]*assembly{}}
+	assemblies.set(indexed)
+	assemblies.set(memory)
+	testMux = http.NewServeMux()
+	registerHandlers(testMux)
+
+	for _, name := range []string{"indexed", "memory"} {
+		b.Run(name, func(b *testing.B) {
+			rnd := rand.New(rand.NewPCG(1, 2))
+			for i := 0; i < b.N; i++ {
+				chr := rnd.IntN(24) + 1
+				start := rnd.IntN(1000000-1000) + 1
+				url := fmt.Sprintf("/v1/%s/sequence?region=chr%d:%d-%d",
+					name, chr, start, start+999)
+				if code, body := do("GET", url, "", ""); code != 200 {
+					b.Fatalf("GET %s status=%v: %s", url, code, body)
+				}
+			}
+		})
+	}
+}
+
+// Writes a random fasta file with the given number of chromosomes and
+// chromosome length.
+func writeBenchmarkFasta(b *testing.B, file string, chroms, length int) {
+	f, err := os.Create(file)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for i := 1; i <= chroms; i++ {
+		fmt.Fprintf(w, ">chr%d\n", i)
+		for j := 0; j < length; j++ {
+			w.WriteByte("ACGT"[rnd.IntN(4)])
+			if (j+1)%60 == 0 || j == length-1 {
+				w.WriteByte('\n')
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+}
@@ -0,0 +1,241 @@
+// Package client accesses fastaserver from Go code.
+//
+// A Client fetches sequences over HTTP. A Local fetches the same data from an
+// indexed fasta file, for use when the server is unavailable or unnecessary.
+// Both implement Fetcher, so code can switch between them freely.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fluhus/golgi/genome"
+)
+
+// Fetcher fetches sequences and chromosome metadata of a genome.
+type Fetcher interface {
+	// Sequence returns the bases of the given chromosome in the 0-based
+	// half-open range [start,end).
+	Sequence(ctx context.Context, chr string, start, end int) ([]byte, error)
+
+	// Batch returns the bases of each of the given regions, in order.
+	Batch(ctx context.Context, regions []Region) ([][]byte, error)
+
+	// Meta returns the chromosomes of the genome.
+	Meta(ctx context.Context) ([]genome.Chrom, error)
+}
+
+// Region is a 0-based half-open genomic range.
+type Region struct {
+	Chr   string
+	Start int
+	End   int
+}
+
+// Returns the region in 1-based inclusive notation, as used by the server.
+// Empty regions end just before their start, like chr1:11-10.
+func (r Region) String() string {
+	return fmt.Sprintf("%s:%d-%d", r.Chr, r.Start+1, r.End)
+}
+
+// Error is an error returned by the server, or an equivalent error of a local
+// fetcher.
+type Error struct {
+	Code    int    `json:"code"`    // HTTP status code
+	Message string `json:"message"` // Error description
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("fastaserver: %d %s", e.Code, e.Message)
+}
+
+// Returns whether the request that caused this error may succeed if retried.
+func (e *Error) temporary() bool {
+	return e.Code >= 500 || e.Code == http.StatusTooManyRequests
+}
+
+// Returns a new error with the given status code.
+func errorf(code int, format string, a ...interface{}) *Error {
+	return &Error{code, fmt.Sprintf(format, a...)}
+}
+
+// ----- CLIENT ----------------------------------------------------------------
+
+// Maximal number of regions in a single batch request, as accepted by the
+// server.
+const maxBatchRegions = 10000
+
+// Client fetches data from a fastaserver. Exported fields may be modified
+// before the first request.
+type Client struct {
+	URL        string        // Server URL, like http://localhost:1912
+	Assembly   string        // Assembly name, empty for the server's default
+	HTTPClient *http.Client  // Client for sending requests
+	Timeout    time.Duration // Timeout of each attempt, 0 for none
+	Retries    int           // Number of retries after a failed attempt
+	RetryWait  time.Duration // Wait before the first retry, doubled each time
+}
+
+// New returns a client for the server at the given URL, with default
+// settings.
+func New(url string) *Client {
+	return &Client{
+		URL:        strings.TrimSuffix(url, "/"),
+		HTTPClient: http.DefaultClient,
+		Timeout:    30 * time.Second,
+		Retries:    3,
+		RetryWait:  100 * time.Millisecond,
+	}
+}
+
+// A fetched sequence, as returned by the server.
+type sequenceResult struct {
+	Sequence string `json:"sequence"`
+}
+
+// Sequence returns the bases of the given chromosome in the 0-based
+// half-open range [start,end).
+func (c *Client) Sequence(ctx context.Context, chr string, start, end int) (
+	[]byte, error) {
+	q := url.Values{}
+	q.Set("chr", chr)
+	q.Set("start", strconv.Itoa(start))
+	q.Set("end", strconv.Itoa(end))
+	var result sequenceResult
+	if err := c.do(ctx, http.MethodGet, "sequence?"+q.Encode(), nil,
+		&result); err != nil {
+		return nil, err
+	}
+	return []byte(result.Sequence), nil
+}
+
+// Batch returns the bases of each of the given regions, in order. Large
+// batches are split to several requests.
+func (c *Client) Batch(ctx context.Context, regions []Region) ([][]byte,
+	error) {
+	result := make([][]byte, 0, len(regions))
+	for i := 0; i < len(regions); i += maxBatchRegions {
+		part := regions[i:min(i+maxBatchRegions, len(regions))]
+		type region struct {
+			Region string `json:"region"`
+		}
+		body := struct {
+			Regions []region `json:"regions"`
+		}{make([]region, len(part))}
+		for i, r := range part {
+			body.Regions[i].Region = r.String()
+		}
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		var response struct {
+			Sequences []sequenceResult `json:"sequences"`
+		}
+		if err := c.do(ctx, http.MethodPost, "sequence", b,
+			&response); err != nil {
+			return nil, err
+		}
+		if len(response.Sequences) != len(part) {
+			return nil, fmt.Errorf("fastaserver: got %d sequences, want %d",
+				len(response.Sequences), len(part))
+		}
+		for _, s := range response.Sequences {
+			result = append(result, []byte(s.Sequence))
+		}
+	}
+	return result, nil
+}
+
+// Meta returns the chromosomes of the genome.
+func (c *Client) Meta(ctx context.Context) ([]genome.Chrom, error) {
+	var result struct {
+		Chromosomes []struct {
+			Name   string `json:"name"`
+			Length int    `json:"length"`
+		} `json:"chromosomes"`
+	}
+	if err := c.do(ctx, http.MethodGet, "meta", nil, &result); err != nil {
+		return nil, err
+	}
+	chroms := make([]genome.Chrom, len(result.Chromosomes))
+	for i, c := range result.Chromosomes {
+		chroms[i] = genome.Chrom{Name: c.Name, Length: c.Length}
+	}
+	return chroms, nil
+}
+
+// Sends a request to the given endpoint and decodes the JSON response into
+// v. Retries on network errors and temporary server errors.
+func (c *Client) do(ctx context.Context, method, endpoint string,
+	body []byte, v interface{}) error {
+	u := c.URL + "/v1/"
+	if c.Assembly != "" {
+		u += url.PathEscape(c.Assembly) + "/"
+	}
+	u += endpoint
+
+	wait := c.RetryWait
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, method, u, body, v)
+		var apiErr *Error
+		if err == nil || attempt >= c.Retries || ctx.Err() != nil ||
+			(errors.As(err, &apiErr) && !apiErr.temporary()) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// Sends a single request and decodes the JSON response into v.
+func (c *Client) attempt(ctx context.Context, method, u string, body []byte,
+	v interface{}) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u,
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct{ Error *Error }
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == nil {
+			return errorf(resp.StatusCode, "%s", http.StatusText(
+				resp.StatusCode))
+		}
+		return e.Error
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("fastaserver: bad response: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body) // Allow connection reuse.
+	return nil
+}
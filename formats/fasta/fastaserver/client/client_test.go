@@ -0,0 +1,286 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fluhus/golgi/genome"
+)
+
+// Opens a local fetcher over a small test genome.
+func openTestLocal(t *testing.T) *Local {
+	file := filepath.Join(t.TempDir(), "test.fa")
+	data := ">chr1\nAACCG\nGTTAC\n>chrM\nggcca\n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := OpenLocal(file)
+	if err != nil {
+		t.Fatalf("OpenLocal(%q) failed: %v", file, err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// A fake fastaserver that serves a local fetcher. Fails the first failures
+// requests with the given status.
+type testServer struct {
+	l        *Local
+	failures int
+	status   int
+	delay    time.Duration
+	requests atomic.Int32
+	mu       sync.Mutex // Guards paths.
+	paths    []string
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	n := int(s.requests.Add(1))
+	s.mu.Lock()
+	s.paths = append(s.paths, req.URL.Path)
+	s.mu.Unlock()
+	time.Sleep(s.delay)
+	if n <= s.failures {
+		writeTestJSON(w, s.status, map[string]*Error{
+			"error": errorf(s.status, "failure #%d", n)})
+		return
+	}
+
+	ctx := req.Context()
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/meta"):
+		chroms, _ := s.l.Meta(ctx)
+		var result []map[string]interface{}
+		for _, c := range chroms {
+			result = append(result, map[string]interface{}{
+				"name": c.Name, "length": c.Length})
+		}
+		writeTestJSON(w, 200, map[string]interface{}{"chromosomes": result})
+
+	case req.Method == http.MethodGet:
+		start, _ := strconv.Atoi(req.FormValue("start"))
+		end, _ := strconv.Atoi(req.FormValue("end"))
+		seq, err := s.l.Sequence(ctx, req.FormValue("chr"), start, end)
+		if err != nil {
+			code := http.StatusInternalServerError
+			var e *Error
+			if errors.As(err, &e) {
+				code = e.Code
+			}
+			writeTestJSON(w, code, map[string]error{"error": err})
+			return
+		}
+		writeTestJSON(w, 200, sequenceResult{string(seq)})
+
+	default:
+		var body struct{ Regions []struct{ Region string } }
+		json.NewDecoder(req.Body).Decode(&body)
+		var result []sequenceResult
+		for _, r := range body.Regions {
+			chr, coords, _ := strings.Cut(r.Region, ":")
+			startS, endS, _ := strings.Cut(coords, "-")
+			start, _ := strconv.Atoi(startS)
+			end, _ := strconv.Atoi(endS)
+			if end < start-1 { // Like the server's parser.
+				writeTestJSON(w, http.StatusBadRequest, map[string]error{
+					"error": errorf(http.StatusBadRequest, "bad end")})
+				return
+			}
+			seq, _ := s.l.Sequence(ctx, chr, start-1, end)
+			result = append(result, sequenceResult{string(seq)})
+		}
+		writeTestJSON(w, 200, map[string]interface{}{"sequences": result})
+	}
+}
+
+// Writes a JSON response with the given status.
+func writeTestJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Starts a test server and returns a client for it.
+func startTestServer(t *testing.T, s *testServer) *Client {
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	c := New(server.URL + "/")
+	c.RetryWait = time.Millisecond
+	return c
+}
+
+func TestClient(t *testing.T) {
+	l := openTestLocal(t)
+	c := startTestServer(t, &testServer{l: l})
+	ctx := context.Background()
+
+	for _, f := range []Fetcher{c, l} {
+		seq, err := f.Sequence(ctx, "chr1", 3, 7)
+		if err != nil {
+			t.Fatalf("%T.Sequence(chr1,3,7) failed: %v", f, err)
+		}
+		if string(seq) != "CGGT" {
+			t.Errorf("%T.Sequence(chr1,3,7)=%q, want CGGT", f, seq)
+		}
+
+		regions := []Region{{"chr1", 0, 2}, {"MT", 1, 5}, {"1", 8, 10}}
+		seqs, err := f.Batch(ctx, regions)
+		if err != nil {
+			t.Fatalf("%T.Batch(%v) failed: %v", f, regions, err)
+		}
+		want := [][]byte{[]byte("AA"), []byte("gcca"), []byte("AC")}
+		if !reflect.DeepEqual(seqs, want) {
+			t.Errorf("%T.Batch(%v)=%q, want %q", f, regions, seqs, want)
+		}
+
+		chroms, err := f.Meta(ctx)
+		if err != nil {
+			t.Fatalf("%T.Meta() failed: %v", f, err)
+		}
+		wantChroms := []genome.Chrom{{Name: "chr1", Length: 10},
+			{Name: "chrM", Length: 5}}
+		if !reflect.DeepEqual(chroms, wantChroms) {
+			t.Errorf("%T.Meta()=%v, want %v", f, chroms, wantChroms)
+		}
+	}
+}
+
+func TestClient_empty(t *testing.T) {
+	l := openTestLocal(t)
+	c := startTestServer(t, &testServer{l: l})
+	ctx := context.Background()
+
+	for _, f := range []Fetcher{c, l} {
+		seq, err := f.Sequence(ctx, "chr1", 4, 4)
+		if err != nil || len(seq) != 0 {
+			t.Errorf("%T.Sequence(chr1,4,4)=%q,%v, want empty", f, seq, err)
+		}
+		regions := []Region{{"chr1", 4, 4}, {"chr1", 0, 1}, {"chrM", 5, 5}}
+		seqs, err := f.Batch(ctx, regions)
+		if err != nil {
+			t.Fatalf("%T.Batch(%v) failed: %v", f, regions, err)
+		}
+		if len(seqs) != 3 || len(seqs[0]) != 0 || string(seqs[1]) != "A" ||
+			len(seqs[2]) != 0 {
+			t.Errorf("%T.Batch(%v)=%q, want [\"\" A \"\"]", f, regions,
+				seqs)
+		}
+	}
+}
+
+func TestClient_errors(t *testing.T) {
+	l := openTestLocal(t)
+	s := &testServer{l: l}
+	c := startTestServer(t, s)
+	ctx := context.Background()
+
+	tests := []struct {
+		chr        string
+		start, end int
+		want       int
+	}{
+		{"chr2", 0, 1, http.StatusNotFound},
+		{"chr1", 5, 11, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		for _, f := range []Fetcher{c, l} {
+			_, err := f.Sequence(ctx, test.chr, test.start, test.end)
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.Code != test.want {
+				t.Errorf("%T.Sequence(%q,%v,%v) error=%v, want code %v", f,
+					test.chr, test.start, test.end, err, test.want)
+			}
+		}
+	}
+	if n := s.requests.Load(); n != 2 {
+		t.Errorf("server got %v requests, want 2 (no retries)", n)
+	}
+}
+
+func TestClient_retries(t *testing.T) {
+	s := &testServer{l: openTestLocal(t), failures: 2,
+		status: http.StatusServiceUnavailable}
+	c := startTestServer(t, s)
+	seq, err := c.Sequence(context.Background(), "chr1", 0, 3)
+	if err != nil || string(seq) != "AAC" {
+		t.Fatalf("Sequence(chr1,0,3)=%q,%v, want AAC", seq, err)
+	}
+	if n := s.requests.Load(); n != 3 {
+		t.Errorf("server got %v requests, want 3", n)
+	}
+
+	s = &testServer{l: openTestLocal(t), failures: 10,
+		status: http.StatusServiceUnavailable}
+	c = startTestServer(t, s)
+	c.Retries = 2
+	if seq, err := c.Sequence(context.Background(), "chr1", 0,
+		3); err == nil {
+		t.Fatalf("Sequence(chr1,0,3)=%q, want error", seq)
+	}
+	if n := s.requests.Load(); n != 3 {
+		t.Errorf("server got %v requests, want 3", n)
+	}
+}
+
+func TestClient_timeout(t *testing.T) {
+	s := &testServer{l: openTestLocal(t), delay: 200 * time.Millisecond}
+	c := startTestServer(t, s)
+	c.Timeout = 10 * time.Millisecond
+	c.Retries = 1
+	if seq, err := c.Sequence(context.Background(), "chr1", 0,
+		3); err == nil {
+		t.Fatalf("Sequence(chr1,0,3)=%q, want error", seq)
+	}
+	if n := s.requests.Load(); n != 2 {
+		t.Errorf("server got %v requests, want 2", n)
+	}
+}
+
+func TestClient_assembly(t *testing.T) {
+	s := &testServer{l: openTestLocal(t)}
+	c := startTestServer(t, s)
+	c.Assembly = "hg38"
+	if _, err := c.Meta(context.Background()); err != nil {
+		t.Fatalf("Meta() failed: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if want := []string{"/v1/hg38/meta"}; !reflect.DeepEqual(s.paths,
+		want) {
+		t.Errorf("server got paths %v, want %v", s.paths, want)
+	}
+}
+
+func TestWithFallback(t *testing.T) {
+	l := openTestLocal(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	c := New(server.URL)
+	c.Retries = 0
+	server.Close() // Unreachable.
+	f := WithFallback(c, l)
+
+	seq, err := f.Sequence(context.Background(), "chr1", 0, 3)
+	if err != nil || string(seq) != "AAC" {
+		t.Fatalf("Sequence(chr1,0,3)=%q,%v, want AAC", seq, err)
+	}
+
+	// Errors of a reachable server are not retried locally.
+	s := &testServer{l: l, failures: 1, status: http.StatusNotFound}
+	f = WithFallback(startTestServer(t, s), l)
+	if seq, err := f.Sequence(context.Background(), "chr1", 0,
+		3); err == nil {
+		t.Fatalf("Sequence(chr1,0,3)=%q, want error", seq)
+	}
+}
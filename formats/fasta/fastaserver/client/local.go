@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/fluhus/golgi/formats/fasta"
+	"github.com/fluhus/golgi/genome"
+)
+
+// Local fetches data from an indexed fasta file, the same way the server
+// does.
+type Local struct {
+	f   *fasta.IndexedFasta
+	gen *genome.Genome
+}
+
+// OpenLocal opens a fasta file for local fetching. Uses the index in
+// file.fai if it exists, otherwise indexes the file.
+func OpenLocal(file string) (*Local, error) {
+	f, err := fasta.OpenIndexed(file)
+	if err != nil {
+		return nil, err
+	}
+	chroms := make([]genome.Chrom, len(f.Entries()))
+	for i, e := range f.Entries() {
+		chroms[i] = genome.Chrom{Name: e.Name, Length: e.Length}
+	}
+	gen, err := genome.New(chroms)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Local{f, gen}, nil
+}
+
+// Close releases the fasta file.
+func (l *Local) Close() error {
+	return l.f.Close()
+}
+
+// Sequence returns the bases of the given chromosome in the 0-based
+// half-open range [start,end).
+func (l *Local) Sequence(ctx context.Context, chr string, start, end int) (
+	[]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	name, ok := l.gen.Canonical(chr)
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "no such chromosome: %q", chr)
+	}
+	if err := l.gen.Validate(name, start, end); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	return l.f.Subsequence(name, start, end)
+}
+
+// Batch returns the bases of each of the given regions, in order.
+func (l *Local) Batch(ctx context.Context, regions []Region) ([][]byte,
+	error) {
+	result := make([][]byte, len(regions))
+	for i, r := range regions {
+		var err error
+		result[i], err = l.Sequence(ctx, r.Chr, r.Start, r.End)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Meta returns the chromosomes of the genome.
+func (l *Local) Meta(ctx context.Context) ([]genome.Chrom, error) {
+	return l.gen.Chroms(), nil
+}
+
+// ----- FALLBACK --------------------------------------------------------------
+
+// WithFallback returns a fetcher that uses primary, and falls back to
+// fallback when primary cannot be reached. Errors returned by a reachable
+// server, like a missing chromosome, are returned as is.
+func WithFallback(primary, fallback Fetcher) Fetcher {
+	return &withFallback{primary, fallback}
+}
+
+// Implements WithFallback.
+type withFallback struct {
+	primary  Fetcher
+	fallback Fetcher
+}
+
+// Returns whether the given error should trigger a fallback.
+func shouldFallBack(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var apiErr *Error
+	return !errors.As(err, &apiErr) || apiErr.temporary()
+}
+
+func (f *withFallback) Sequence(ctx context.Context, chr string, start,
+	end int) ([]byte, error) {
+	result, err := f.primary.Sequence(ctx, chr, start, end)
+	if shouldFallBack(ctx, err) {
+		return f.fallback.Sequence(ctx, chr, start, end)
+	}
+	return result, err
+}
+
+func (f *withFallback) Batch(ctx context.Context, regions []Region) (
+	[][]byte, error) {
+	result, err := f.primary.Batch(ctx, regions)
+	if shouldFallBack(ctx, err) {
+		return f.fallback.Batch(ctx, regions)
+	}
+	return result, err
+}
+
+func (f *withFallback) Meta(ctx context.Context) ([]genome.Chrom, error) {
+	result, err := f.primary.Meta(ctx)
+	if shouldFallBack(ctx, err) {
+		return f.fallback.Meta(ctx)
+	}
+	return result, err
+}
@@ -4,12 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-
-	"github.com/fluhus/golgi/formats/fasta"
 )
 
-// Handles sequence requests.
-func sequenceHandler(w http.ResponseWriter, req *http.Request) {
+// Handles sequence requests. Kept for clients of the unversioned API; new
+// clients should use /v1/sequence.
+func sequenceHandler(w http.ResponseWriter, req *http.Request,
+	a *assembly) {
 	report("Got sequence request.")
 
 	chr := req.FormValue("chr")
@@ -17,62 +17,71 @@ func sequenceHandler(w http.ResponseWriter, req *http.Request) {
 	lengthS := req.FormValue("length")
 
 	if chr == "" {
-		fmt.Fprintf(w, "Error: Empty chromosome name.")
+		legacyError(w, http.StatusBadRequest, "Empty chromosome name.")
 		return
 	}
 
 	start, err := strconv.Atoi(startS)
 	if err != nil {
-		fmt.Fprintf(w, "Error: Bad start position: '%s'", startS)
+		legacyError(w, http.StatusBadRequest, "Bad start position: '%s'",
+			startS)
 		return
 	}
 
 	length, err := strconv.Atoi(lengthS)
 	if err != nil {
-		fmt.Fprintf(w, "Error: Bad length: '%s'", lengthS)
+		legacyError(w, http.StatusBadRequest, "Bad length: '%s'", lengthS)
 		return
 	}
 
-	// Find fasta.
-	var entry *fasta.Fasta
-	for _, e := range fa {
-		if string(e.Name) == chr {
-			entry = e
-		}
-	}
-
-	if entry == nil {
-		fmt.Fprintf(w, "Error: No such chromosome: '%s'.", chr)
+	// Find fasta, allowing chromosome aliases.
+	name, ok := a.gen.Canonical(chr)
+	if !ok {
+		legacyError(w, http.StatusNotFound, "No such chromosome: '%s'.", chr)
 		return
 	}
 
 	// Check positions.
 	if length < 1 {
-		fmt.Fprintf(w, "Error: Invalid length: %d.", length)
+		legacyError(w, http.StatusBadRequest, "Invalid length: %d.", length)
 		return
 	}
 
 	if start < 0 {
-		fmt.Fprintf(w, "Error: Invalid start position: %d.", start)
+		legacyError(w, http.StatusBadRequest, "Invalid start position: %d.",
+			start)
 		return
 	}
 
-	if start+length > len(entry.Sequence) {
-		fmt.Fprintf(w, "Error: Position exceeds chromosome length (max %d).",
-			len(entry.Sequence))
+	if err := a.gen.Validate(name, start, start+length); err != nil {
+		max, _ := a.gen.Length(name)
+		legacyError(w, http.StatusBadRequest,
+			"Position exceeds chromosome length (max %d).", max)
 		return
 	}
 
 	// Everything is ok!
+	seq, err := a.seqs.Subsequence(name, start, start+length)
+	if err != nil {
+		legacyError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
 	reportf("chr=%s start=%d len=%d\n", chr, start, length)
-	seq := entry.Sequence[start : start+length]
 	w.Write(seq)
 }
 
-// Handles metadata requests.
-func metaHandler(w http.ResponseWriter, req *http.Request) {
+// Handles metadata requests. Kept for clients of the unversioned API; new
+// clients should use /v1/meta.
+func metaHandler(w http.ResponseWriter, req *http.Request, a *assembly) {
 	reportf("Got meta request.")
-	for _, entry := range fa {
-		fmt.Fprintf(w, "%s: %d\n", entry.Name, len(entry.Sequence))
+	for _, c := range a.gen.Chroms() {
+		fmt.Fprintf(w, "%s: %d\n", c.Name, c.Length)
 	}
 }
+
+// Writes an error of the unversioned API, with the given status code.
+func legacyError(w http.ResponseWriter, code int, format string,
+	a ...interface{}) {
+	reportf("Error %d: %s\n", code, fmt.Sprintf(format, a...))
+	http.Error(w, "Error: "+fmt.Sprintf(format, a...), code)
+}
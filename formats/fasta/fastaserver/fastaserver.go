@@ -5,11 +5,15 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/fluhus/golgi/formats/fasta"
+	"github.com/fluhus/golgi/genome"
 )
 
 func main() {
@@ -17,6 +21,7 @@ func main() {
 	if len(os.Args) == 1 { // No arguments
 		fmt.Println("A server for querying fasta files.")
 		fmt.Println("\nUsage:\nfastaserver [options] myfile.fasta")
+		fmt.Println("fastaserver [options] -config assemblies.txt")
 		fmt.Println("\nOptions:")
 		flag.PrintDefaults()
 		os.Exit(1)
@@ -27,53 +32,101 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Read fasta file.
-	fmt.Println("Reading fasta...")
-	now := time.Now()
-	var err error
-	fa, err = readFastaFile(args.file)
+	// Read fasta files.
+	for _, c := range args.assemblies {
+		fmt.Printf("Reading %s from %s...\n", c.name, c.file)
+		now := time.Now()
+		a, err := loadAssembly(c.name, c.file)
+		if err != nil {
+			fmt.Println("Error reading fasta:", err)
+			os.Exit(2)
+		}
+		assemblies.set(a)
+		reportf("Took %v.\n", time.Since(now))
+	}
+
+	// Reload on SIGHUP or on file change.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			assemblies.reload(true)
+		}
+	}()
+	if *args.watch > 0 {
+		go assemblies.watch(*args.watch)
+	}
+
+	accessLog, err := openAccessLog(*args.accessLog)
 	if err != nil {
-		fmt.Println("Error reading fasta:", err)
+		fmt.Println("Error opening access log:", err)
 		os.Exit(2)
 	}
-	reportf("Took %v.\n", time.Since(now))
 
-	fmt.Print("Ready! Listening on port ", *args.port, ". Hit ctrl+C to"+
-		" exit.\n")
+	addr := net.JoinHostPort(*args.addr, *args.port)
+	fmt.Print("Ready! Listening on ", addr, ". Hit ctrl+C to exit.\n")
 
 	// Listen on port.
-	http.HandleFunc("/sequence", sequenceHandler)
-	http.HandleFunc("/meta", metaHandler)
-	err = http.ListenAndServe(":"+*args.port, nil)
+	mux := http.NewServeMux()
+	registerHandlers(mux)
+	err = serve(addr, instrument(mux, accessLog))
 	if err != nil {
 		fmt.Println("Error listening:", err)
+		os.Exit(2)
 	}
 }
 
 var args struct {
-	port    *string
-	verbose *bool
-	file    string
-	err     error
+	port       *string
+	addr       *string
+	accessLog  *string
+	verbose    *bool
+	watch      *time.Duration
+	assemblies []assemblyConfig
+	err        error
 }
 
 // Parses command-line arguments and places everything in args.
 // args.err will be non-nil if a parsing error occurred.
 func parseArguments() {
 	args.port = flag.String("port", "1912", "Port number to listen on.")
+	args.addr = flag.String("addr", "", "Address to bind to, like "+
+		"127.0.0.1. Default is all interfaces.")
+	args.accessLog = flag.String("log", "", "Access log file, in JSON "+
+		"lines. Use - for standard error. Default is no access log.")
 	args.verbose = flag.Bool("v", false, "Print out lots of stuff.")
+	config := flag.String("config", "", "Assembly configuration file. "+
+		"Each line has an assembly name and a fasta file path.")
+	args.watch = flag.Duration("watch", 0, "Interval for checking fasta "+
+		"files for changes, like 1m. Default is no checking. Files should "+
+		"be updated by replacing them, not by writing over them.")
 	flag.Parse()
 
+	if *config != "" {
+		if len(flag.Args()) != 0 {
+			args.err = fmt.Errorf("cannot use both a configuration file " +
+				"and a fasta input")
+			return
+		}
+		args.assemblies, args.err = readConfig(*config)
+		return
+	}
+
 	if len(flag.Args()) == 0 {
 		args.err = fmt.Errorf("no fasta input given")
 		return
 	}
-	if len(flag.Args()) == 0 {
+	if len(flag.Args()) > 1 {
 		args.err = fmt.Errorf("too many arguments")
 		return
 	}
 
-	args.file = flag.Arg(0)
+	name := assemblyName(flag.Arg(0))
+	if err := checkAssemblyName(name); err != nil {
+		args.err = err
+		return
+	}
+	args.assemblies = []assemblyConfig{{name, flag.Arg(0)}}
 }
 
 // Returns a fasta object from the given file.
@@ -96,8 +149,15 @@ func readFastaFile(file string) ([]*fasta.Fasta, error) {
 	return fas, nil
 }
 
-// All fasta data will be here.
-var fa []*fasta.Fasta
+// Returns the chromosome names and lengths of the given fasta entries.
+func genomeOf(fa []*fasta.Fasta) (*genome.Genome, error) {
+	chroms := make([]genome.Chrom, len(fa))
+	for i, entry := range fa {
+		chroms[i] = genome.Chrom{Name: string(entry.Name),
+			Length: len(entry.Sequence)}
+	}
+	return genome.New(chroms)
+}
 
 // Print if verbose.
 func report(a ...interface{}) {
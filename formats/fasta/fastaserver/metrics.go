@@ -0,0 +1,145 @@
+package main
+
+// Request metrics, exposed in Prometheus text format.
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Upper bounds of latency histogram buckets, in seconds.
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// Counts requests and their latencies.
+type metrics struct {
+	mu       sync.Mutex
+	requests map[requestKey]int    // Request counts.
+	latency  map[string]*histogram // Latencies by route.
+	inFlight atomic.Int64          // Requests being handled.
+	start    time.Time             // Time the metrics were created.
+}
+
+// Identifies a group of requests for counting.
+type requestKey struct {
+	route  string
+	method string
+	code   int
+}
+
+// A latency histogram.
+type histogram struct {
+	counts []int // Cumulative count per bucket.
+	sum    float64
+	count  int
+}
+
+// Request metrics of the server.
+var serverMetrics = newMetrics()
+
+// Returns empty metrics.
+func newMetrics() *metrics {
+	return &metrics{requests: map[requestKey]int{},
+		latency: map[string]*histogram{}, start: time.Now()}
+}
+
+// Records a handled request.
+func (m *metrics) observe(route, method string, code int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{route, method, code}]++
+	h := m.latency[route]
+	if h == nil {
+		h = &histogram{counts: make([]int, len(latencyBuckets))}
+		m.latency[route] = h
+	}
+	sec := d.Seconds()
+	for i, b := range latencyBuckets {
+		if sec <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += sec
+	h.count++
+}
+
+// Writes the metrics in Prometheus text format.
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP fastaserver_requests_total Number of handled "+
+		"HTTP requests.")
+	fmt.Fprintln(w, "# TYPE fastaserver_requests_total counter")
+	keys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "fastaserver_requests_total{route=%s,method=%s,"+
+			"code=\"%d\"} %d\n", quoteLabel(k.route), quoteLabel(k.method),
+			k.code, m.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP fastaserver_request_duration_seconds Latency "+
+		"of HTTP requests.")
+	fmt.Fprintln(w, "# TYPE fastaserver_request_duration_seconds histogram")
+	routes := make([]string, 0, len(m.latency))
+	for r := range m.latency {
+		routes = append(routes, r)
+	}
+	sort.Strings(routes)
+	for _, r := range routes {
+		h := m.latency[r]
+		for i, b := range latencyBuckets {
+			fmt.Fprintf(w, "fastaserver_request_duration_seconds_bucket{"+
+				"route=%s,le=\"%g\"} %d\n", quoteLabel(r), b, h.counts[i])
+		}
+		fmt.Fprintf(w, "fastaserver_request_duration_seconds_bucket{"+
+			"route=%s,le=\"+Inf\"} %d\n", quoteLabel(r), h.count)
+		fmt.Fprintf(w, "fastaserver_request_duration_seconds_sum{"+
+			"route=%s} %g\n", quoteLabel(r), h.sum)
+		fmt.Fprintf(w, "fastaserver_request_duration_seconds_count{"+
+			"route=%s} %d\n", quoteLabel(r), h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP fastaserver_requests_in_flight Number of "+
+		"requests being handled.")
+	fmt.Fprintln(w, "# TYPE fastaserver_requests_in_flight gauge")
+	fmt.Fprintln(w, "fastaserver_requests_in_flight", m.inFlight.Load())
+
+	fmt.Fprintln(w, "# HELP fastaserver_uptime_seconds Time since the "+
+		"server started.")
+	fmt.Fprintln(w, "# TYPE fastaserver_uptime_seconds gauge")
+	fmt.Fprintf(w, "fastaserver_uptime_seconds %g\n",
+		time.Since(m.start).Seconds())
+}
+
+// Returns a quoted Prometheus label value.
+func quoteLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// Handles metrics requests.
+func metricsHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	serverMetrics.write(w)
+}
@@ -0,0 +1,137 @@
+package main
+
+// Running the server as a service: limits, access logs, health checks and
+// graceful shutdown.
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Maximal size of request headers, including the URL, in bytes.
+const maxHeaderBytes = 64 << 10
+
+// Time to wait for in-flight requests on shutdown.
+const shutdownTimeout = 30 * time.Second
+
+// Records the status and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	code  int
+	bytes int
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Returns the underlying writer, for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Wraps a handler with a request body limit, metrics and access logs. log
+// may be nil for no access logs.
+func instrument(h http.Handler, log *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		serverMetrics.inFlight.Add(1)
+		defer serverMetrics.inFlight.Add(-1)
+
+		req.Body = http.MaxBytesReader(w, req.Body, maxBatchBody)
+		rec := &responseRecorder{w, http.StatusOK, 0}
+		h.ServeHTTP(rec, req)
+		d := time.Since(start)
+
+		// Pattern is set by the mux on the same request.
+		route := req.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		serverMetrics.observe(route, req.Method, rec.code, d)
+		if log != nil {
+			log.LogAttrs(req.Context(), slog.LevelInfo, "request",
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("query", req.URL.RawQuery),
+				slog.String("route", route),
+				slog.Int("status", rec.code),
+				slog.Int("bytes", rec.bytes),
+				slog.Float64("duration_ms", float64(d.Microseconds())/1000),
+				slog.String("remote", req.RemoteAddr),
+				slog.String("user_agent", req.UserAgent()),
+			)
+		}
+	})
+}
+
+// Returns a JSON access logger that writes to the given file, "-" for
+// standard error, or nil if file is empty.
+func openAccessLog(file string) (*slog.Logger, error) {
+	switch file {
+	case "":
+		return nil, nil
+	case "-":
+		return slog.New(slog.NewJSONHandler(os.Stderr, nil)), nil
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return slog.New(slog.NewJSONHandler(f, nil)), nil
+}
+
+// Handles health check requests. Healthy when the default assembly is
+// loaded.
+func healthHandler(w http.ResponseWriter, req *http.Request) {
+	a := assemblies.get("")
+	if a == nil {
+		http.Error(w, "no assemblies loaded", http.StatusServiceUnavailable)
+		return
+	}
+	a.release()
+	fmt.Fprintln(w, "ok")
+}
+
+// Serves HTTP on the given address until SIGTERM or interrupt, then waits
+// for in-flight requests to finish.
+func serve(addr string, h http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	done := make(chan error, 1)
+	go func() {
+		<-stop
+		fmt.Println("Shutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(),
+			shutdownTimeout)
+		defer cancel()
+		done <- server.Shutdown(ctx)
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return <-done
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInstrument(t *testing.T) {
+	setUpTest(t)
+	serverMetrics = newMetrics()
+	buf := &bytes.Buffer{}
+	h := instrument(testMux, slog.New(slog.NewJSONHandler(buf, nil)))
+
+	urls := []string{"/v1/test2/meta", "/v1/test1/meta", "/v1/test3/meta",
+		"/nothing"}
+	for _, url := range urls {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", url,
+			nil))
+	}
+
+	w := httptest.NewRecorder()
+	testMux.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	got := w.Body.String()
+	want := []string{
+		`fastaserver_requests_total{route="/v1/{assembly}/meta",` +
+			`method="GET",code="200"} 2`,
+		`fastaserver_requests_total{route="/v1/{assembly}/meta",` +
+			`method="GET",code="404"} 1`,
+		`fastaserver_requests_total{route="unmatched",method="GET",` +
+			`code="404"} 1`,
+		`fastaserver_request_duration_seconds_count{` +
+			`route="/v1/{assembly}/meta"} 3`,
+		`fastaserver_request_duration_seconds_bucket{` +
+			`route="unmatched",le="+Inf"} 1`,
+		"fastaserver_requests_in_flight 0",
+	}
+	for _, line := range want {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("metrics do not contain %q:\n%s", line, got)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(urls) {
+		t.Fatalf("got %v access log lines, want %v", len(lines), len(urls))
+	}
+	var entry struct {
+		Path   string
+		Route  string
+		Status int
+		Bytes  int
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("bad access log line: %v", err)
+	}
+	if entry.Path != "/v1/test2/meta" || entry.Route != "/v1/{assembly}/meta" ||
+		entry.Status != 200 || entry.Bytes == 0 {
+		t.Errorf("access log=%+v, want path /v1/test2/meta and status 200",
+			entry)
+	}
+}
+
+func TestInstrument_bodyLimit(t *testing.T) {
+	setUpTest(t)
+	h := instrument(testMux, nil)
+	body := `{"regions": [{"region": "chr1"}], "x": "` +
+		strings.Repeat("A", maxBatchBody) + `"}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/v1/sequence",
+		strings.NewReader(body)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST status=%v, want %v", w.Code,
+			http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	setUpTest(t)
+	if code, got := do("GET", "/healthz", "", ""); code != http.StatusOK ||
+		got != "ok\n" {
+		t.Errorf("GET /healthz=%v,%q, want 200,ok", code, got)
+	}
+	assemblies = &registry{byName: map[string]*assembly{}}
+	if code, _ := do("GET", "/healthz", "", ""); code !=
+		http.StatusServiceUnavailable {
+		t.Errorf("GET /healthz status=%v, want 503", code)
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/v1/meta", `"/v1/meta"`},
+		{`a"b\c` + "\n", `"a\"b\\c\n"`},
+	}
+	for _, test := range tests {
+		if got := quoteLabel(test.input); got != test.want {
+			t.Errorf("quoteLabel(%q)=%q, want %q", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package fasta
+
+// Random access to indexed fasta files.
+
+import (
+	"fmt"
+	"os"
+)
+
+// IndexedFasta gives random access to the sequences of a fasta file, using
+// its index. The file is memory-mapped, so sequences are read from the page
+// cache rather than held on the heap.
+type IndexedFasta struct {
+	data    []byte               // File contents
+	entries []*FaiEntry          // Index entries in file order
+	byName  map[string]*FaiEntry // Index entries by name
+}
+
+// OpenIndexed opens a fasta file for random access. Uses the index in
+// file.fai if it exists and is not older than the file, otherwise indexes
+// the file.
+//
+// The file should not be modified while open. To update it, replace it with
+// a new file.
+func OpenIndexed(file string) (*IndexedFasta, error) {
+	var entries []*FaiEntry
+	var err error
+	if isNewer(file+".fai", file) {
+		entries, err = readFaiFile(file + ".fai")
+	} else {
+		entries, err = makeFaiFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := mmapFile(file)
+	if err != nil {
+		return nil, err
+	}
+	result := &IndexedFasta{data, entries, map[string]*FaiEntry{}}
+	for _, e := range entries {
+		if result.byName[e.Name] != nil {
+			result.Close()
+			return nil, fmt.Errorf("duplicate sequence name: %q", e.Name)
+		}
+		if e.Length > 0 && result.offset(e, e.Length-1) >= int64(len(data)) {
+			result.Close()
+			return nil, fmt.Errorf("sequence %q exceeds the end of %s",
+				e.Name, file)
+		}
+		result.byName[e.Name] = e
+	}
+	return result, nil
+}
+
+// Returns whether file a exists and was modified no earlier than file b.
+func isNewer(a, b string) bool {
+	sa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	sb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return !sa.ModTime().Before(sb.ModTime())
+}
+
+// Reads a fasta index file.
+func readFaiFile(file string) ([]*FaiEntry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadFai(f)
+}
+
+// Indexes a fasta file.
+func makeFaiFile(file string) ([]*FaiEntry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return MakeFai(f)
+}
+
+// Entries returns the index entries of the sequences, in file order.
+func (f *IndexedFasta) Entries() []*FaiEntry {
+	return f.entries
+}
+
+// Length returns the number of bases in the given sequence, and whether it
+// exists.
+func (f *IndexedFasta) Length(name string) (int, bool) {
+	e := f.byName[name]
+	if e == nil {
+		return 0, false
+	}
+	return e.Length, true
+}
+
+// Subsequence returns a copy of the bases of the given sequence in the
+// 0-based half-open range [start,end).
+func (f *IndexedFasta) Subsequence(name string, start, end int) ([]byte,
+	error) {
+	e := f.byName[name]
+	if e == nil {
+		return nil, fmt.Errorf("no such sequence: %q", name)
+	}
+	if start < 0 || end < start || end > e.Length {
+		return nil, fmt.Errorf("bad range for %q (length %d): %d-%d", name,
+			e.Length, start, end)
+	}
+
+	result := make([]byte, 0, end-start)
+	for start < end {
+		n := min(e.LineBases-start%e.LineBases, end-start)
+		offset := f.offset(e, start)
+		result = append(result, f.data[offset:offset+int64(n)]...)
+		start += n
+	}
+	return result, nil
+}
+
+// Returns the byte offset of the given base in the file.
+func (f *IndexedFasta) offset(e *FaiEntry, pos int) int64 {
+	line, col := pos/e.LineBases, pos%e.LineBases
+	return e.Offset + int64(line)*int64(e.LineWidth) + int64(col)
+}
+
+// Close releases the memory-mapped file. The IndexedFasta cannot be used
+// afterwards.
+func (f *IndexedFasta) Close() error {
+	data := f.data
+	f.data = nil
+	return munmap(data)
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package fasta
+
+import "os"
+
+// Reads the given file to memory. Memory mapping is not supported on this
+// platform.
+func mmapFile(file string) ([]byte, error) {
+	return os.ReadFile(file)
+}
+
+// Releases data that was returned by mmapFile.
+func munmap(data []byte) error {
+	return nil
+}
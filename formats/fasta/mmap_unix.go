@@ -0,0 +1,34 @@
+//go:build unix
+
+package fasta
+
+import (
+	"os"
+	"syscall"
+)
+
+// Maps the given file to memory, read only.
+func mmapFile(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() == 0 {
+		return nil, nil
+	}
+	return syscall.Mmap(int(f.Fd()), 0, int(stat.Size()), syscall.PROT_READ,
+		syscall.MAP_SHARED)
+}
+
+// Unmaps data that was returned by mmapFile.
+func munmap(data []byte) error {
+	if data == nil {
+		return nil
+	}
+	return syscall.Munmap(data)
+}
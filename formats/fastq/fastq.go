@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"iter"
 )
 
 // Fastq represents a single Fastq entry.
@@ -83,6 +84,22 @@ func (r *Reader) Next() (*Fastq, error) {
 	return &Fastq{name, seq, quals}, nil
 }
 
+// All returns an iterator over the remaining entries. Stops after the first
+// error, which is yielded with a nil entry. EOF is not yielded.
+func (r *Reader) All() iter.Seq2[*Fastq, error] {
+	return func(yield func(*Fastq, error) bool) {
+		for {
+			x, err := r.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(x, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
 // Copies the given bytes to a newly allocated slice.
 func copyBytes(src []byte) []byte {
 	b := make([]byte, len(src))
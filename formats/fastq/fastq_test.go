@@ -53,3 +53,26 @@ func TestNext_many(t *testing.T) {
 		t.Fatalf("ForEach(%q)=%v, want %v", input, got, want)
 	}
 }
+
+func TestAll(t *testing.T) {
+	input := "@a\nAAT\n+\n!!!\n@b\nGC\n+\n@@\n@c\nAAA\n-\n!!!\n"
+	want := []*Fastq{
+		{[]byte("a"), []byte("AAT"), []byte("!!!")},
+		{[]byte("b"), []byte("GC"), []byte("@@")},
+	}
+	var got []*Fastq
+	var gotErr error
+	for fq, err := range NewReader(strings.NewReader(input)).All() {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		got = append(got, fq)
+	}
+	if gotErr == nil {
+		t.Fatalf("All(%q) succeeded, want error", input)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("All(%q)=%v, want %v", input, got, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"iter"
 	"strconv"
 	"strings"
 
@@ -137,6 +138,22 @@ func (r *Reader) Next() (*SAM, error) {
 	return fromRaw(raw)
 }
 
+// All returns an iterator over the remaining SAM lines. Stops after the first
+// error, which is yielded with a nil line. EOF is not yielded.
+func (r *Reader) All() iter.Seq2[*SAM, error] {
+	return func(yield func(*SAM, error) bool) {
+		for {
+			x, err := r.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(x, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
 // Returns a map from tag name to its parsed (typed) value.
 func parseTags(values []string) (map[string]interface{}, error) {
 	result := map[string]interface{}{}
@@ -123,3 +123,27 @@ func TestDecoder_tags(t *testing.T) {
 		t.Fatalf("Next()=%v, want %v", got, want)
 	}
 }
+
+func TestReader_all(t *testing.T) {
+	input := "@a\n" +
+		"c\t2\td\t5\t30\t32M\te\t40\t50\tAAAA\tFFFF\n" +
+		"f\t6\tg\t10\t60\t4D\th\t70\t80\tTCTC\t!!!!\n"
+	r := NewReader(bytes.NewBuffer([]byte(input)))
+
+	want := []*SAM{
+		{"c", 2, "d", 5, 30, "32M", "e", 40, 50, "AAAA", "FFFF",
+			map[string]interface{}{}},
+		{"f", 6, "g", 10, 60, "4D", "h", 70, 80, "TCTC", "!!!!",
+			map[string]interface{}{}},
+	}
+	var got []*SAM
+	for sm, err := range r.All() {
+		if err != nil {
+			t.Fatalf("All() failed: %v", err)
+		}
+		got = append(got, sm)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("All()=%v, want %v", got, want)
+	}
+}
@@ -0,0 +1,300 @@
+// Package twobit reads and writes genomes in the UCSC .2bit format.
+//
+// A .2bit file holds named DNA sequences, packed 4 bases per byte. Runs of N
+// and lowercase (soft-masked) runs are kept as blocks, so a genome can be
+// converted from fasta and back without loss. Other IUPAC codes are stored
+// as N.
+//
+// The format is described in:
+// https://genome.ucsc.edu/FAQ/FAQformat.html#format7
+package twobit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Signature at the beginning of .2bit files.
+const signature = 0x1A412743
+
+// Packed values of bases.
+const (
+	baseT = 0
+	baseC = 1
+	baseA = 2
+	baseG = 3
+)
+
+// A run of bases, 0-based half-open.
+type block struct {
+	start int
+	end   int
+}
+
+// Returns the blocks where f is true for the bases of seq.
+func findBlocks(seq []byte, f func(byte) bool) []block {
+	var result []block
+	for i := 0; i < len(seq); i++ {
+		if !f(seq[i]) {
+			continue
+		}
+		start := i
+		for i < len(seq) && f(seq[i]) {
+			i++
+		}
+		result = append(result, block{start, i})
+	}
+	return result
+}
+
+// Returns the index of the first block that ends after pos.
+func firstBlockAfter(blocks []block, pos int) int {
+	return sort.Search(len(blocks), func(i int) bool {
+		return blocks[i].end > pos
+	})
+}
+
+// ----- READER ----------------------------------------------------------------
+
+// Reader gives random access to the sequences of a .2bit file. A Reader is
+// safe for concurrent use if the underlying ReaderAt is.
+type Reader struct {
+	r     io.ReaderAt
+	names []string
+	seqs  map[string]*sequence
+}
+
+// Location and blocks of a single sequence in the file.
+type sequence struct {
+	size   int     // Number of bases.
+	nBlks  []block // Runs of N.
+	mask   []block // Lowercase runs.
+	offset int64   // Offset of the packed bases.
+}
+
+// NewReader reads the index of a .2bit file and returns a reader of its
+// sequences.
+func NewReader(r io.ReaderAt) (*Reader, error) {
+	var header [16]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return nil, fmt.Errorf("reading header: %v", err)
+	}
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(header[:]) == signature:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(header[:]) == signature:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("bad signature: %x", header[:4])
+	}
+	version := order.Uint32(header[4:])
+	if version > 1 {
+		return nil, fmt.Errorf("unsupported version: %d", version)
+	}
+	count := int(order.Uint32(header[8:]))
+
+	fr := &fileReader{r, order, 16}
+	result := &Reader{r, nil, map[string]*sequence{}}
+	offsets := make([]int64, count)
+	for i := range offsets {
+		nameSize, err := fr.bytes(1)
+		if err != nil {
+			return nil, fmt.Errorf("reading index: %v", err)
+		}
+		name, err := fr.bytes(int(nameSize[0]))
+		if err != nil {
+			return nil, fmt.Errorf("reading index: %v", err)
+		}
+		if version == 0 {
+			var offset uint32
+			offset, err = fr.uint32()
+			offsets[i] = int64(offset)
+		} else {
+			var offset uint64
+			offset, err = fr.uint64()
+			offsets[i] = int64(offset)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading index: %v", err)
+		}
+		if result.seqs[string(name)] != nil {
+			return nil, fmt.Errorf("duplicate sequence name: %q", name)
+		}
+		result.names = append(result.names, string(name))
+		result.seqs[string(name)] = nil
+	}
+
+	for i, name := range result.names {
+		fr.pos = offsets[i]
+		seq, err := fr.sequence()
+		if err != nil {
+			return nil, fmt.Errorf("reading sequence %q: %v", name, err)
+		}
+		result.seqs[name] = seq
+	}
+	return result, nil
+}
+
+// Names returns the names of the sequences in file order.
+func (r *Reader) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Length returns the number of bases in the given sequence, and whether it
+// exists.
+func (r *Reader) Length(name string) (int, bool) {
+	seq := r.seqs[name]
+	if seq == nil {
+		return 0, false
+	}
+	return seq.size, true
+}
+
+// Sequence returns the bases of the given sequence.
+func (r *Reader) Sequence(name string) ([]byte, error) {
+	n, ok := r.Length(name)
+	if !ok {
+		return nil, fmt.Errorf("no such sequence: %q", name)
+	}
+	return r.Subsequence(name, 0, n)
+}
+
+// Subsequence returns the bases of the given sequence in the 0-based
+// half-open range [start,end). Masked bases are lowercase.
+func (r *Reader) Subsequence(name string, start, end int) ([]byte, error) {
+	seq := r.seqs[name]
+	if seq == nil {
+		return nil, fmt.Errorf("no such sequence: %q", name)
+	}
+	if start < 0 || end < start || end > seq.size {
+		return nil, fmt.Errorf("bad range for %q (length %d): %d-%d", name,
+			seq.size, start, end)
+	}
+	if start == end {
+		return []byte{}, nil
+	}
+
+	// Unpack bases.
+	packed := make([]byte, (end+3)/4-start/4)
+	if _, err := r.r.ReadAt(packed, seq.offset+int64(start/4)); err != nil {
+		return nil, fmt.Errorf("reading sequence %q: %v", name, err)
+	}
+	result := make([]byte, 0, len(packed)*4)
+	for _, b := range packed {
+		result = append(result, unpacked[b][:]...)
+	}
+	result = result[start%4 : start%4+end-start]
+
+	// Apply blocks.
+	for i := firstBlockAfter(seq.nBlks, start); i < len(seq.nBlks) &&
+		seq.nBlks[i].start < end; i++ {
+		b := seq.nBlks[i]
+		for j := max(b.start, start); j < min(b.end, end); j++ {
+			result[j-start] = 'N'
+		}
+	}
+	for i := firstBlockAfter(seq.mask, start); i < len(seq.mask) &&
+		seq.mask[i].start < end; i++ {
+		b := seq.mask[i]
+		for j := max(b.start, start); j < min(b.end, end); j++ {
+			result[j-start] += 'a' - 'A'
+		}
+	}
+	return result, nil
+}
+
+// Maps a packed byte to its 4 bases.
+var unpacked [256][4]byte
+
+func init() {
+	bases := [4]byte{baseT: 'T', baseC: 'C', baseA: 'A', baseG: 'G'}
+	for i := range unpacked {
+		for j := 0; j < 4; j++ {
+			// First base is the most significant.
+			unpacked[i][j] = bases[(i>>(6-2*j))&3]
+		}
+	}
+}
+
+// Reads values from a position in a file.
+type fileReader struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+	pos   int64
+}
+
+// Reads n bytes and advances the position.
+func (r *fileReader) bytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := r.r.ReadAt(b, r.pos); err != nil {
+		return nil, err
+	}
+	r.pos += int64(n)
+	return b, nil
+}
+
+// Reads a 32-bit number and advances the position.
+func (r *fileReader) uint32() (uint32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return r.order.Uint32(b), nil
+}
+
+// Reads a 64-bit number and advances the position.
+func (r *fileReader) uint64() (uint64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return r.order.Uint64(b), nil
+}
+
+// Reads a list of blocks, as starts followed by sizes.
+func (r *fileReader) blocks(size int) ([]block, error) {
+	count, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if int64(count) > int64(size) {
+		return nil, fmt.Errorf("too many blocks: %d", count)
+	}
+	b, err := r.bytes(int(count) * 8)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]block, count)
+	for i := range result {
+		start := int(r.order.Uint32(b[i*4:]))
+		end := start + int(r.order.Uint32(b[(int(count)+i)*4:]))
+		if end > size || (i > 0 && start < result[i-1].end) {
+			return nil, fmt.Errorf("bad block: %d-%d", start, end)
+		}
+		result[i] = block{start, end}
+	}
+	return result, nil
+}
+
+// Reads a sequence record, without its bases.
+func (r *fileReader) sequence() (*sequence, error) {
+	size, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	seq := &sequence{size: int(size)}
+	if seq.nBlks, err = r.blocks(seq.size); err != nil {
+		return nil, err
+	}
+	if seq.mask, err = r.blocks(seq.size); err != nil {
+		return nil, err
+	}
+	if _, err := r.uint32(); err != nil { // Reserved.
+		return nil, err
+	}
+	seq.offset = r.pos
+	return seq, nil
+}
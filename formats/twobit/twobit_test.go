@@ -0,0 +1,155 @@
+package twobit
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/golgi/formats/fasta"
+)
+
+// Converts a fasta string to .2bit and returns a reader of the result.
+func fromFastaString(t *testing.T, input string) *Reader {
+	buf := &bytes.Buffer{}
+	if err := FromFasta(buf, fasta.NewReader(strings.NewReader(
+		input))); err != nil {
+		t.Fatalf("FromFasta(%q) failed: %v", input, err)
+	}
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader(FromFasta(%q)) failed: %v", input, err)
+	}
+	return r
+}
+
+func TestRoundTrip(t *testing.T) {
+	input := ">chr1\nNNACGTacgtNNnnGGccNN\n>chr2\nTTTGa\n>empty\n" +
+		">chr3\nnnnnn\n>chr4\nACGTACGTA\n"
+	want, err := collect(fasta.NewReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := fromFastaString(t, input)
+	if got := r.Names(); !reflect.DeepEqual(got, []string{"chr1", "chr2",
+		"empty", "chr3", "chr4"}) {
+		t.Errorf("Names()=%v, want chr1, chr2, empty, chr3, chr4", got)
+	}
+	var got []*fasta.Fasta
+	for _, name := range r.Names() {
+		seq, err := r.Sequence(name)
+		if err != nil {
+			t.Fatalf("Sequence(%q) failed: %v", name, err)
+		}
+		got = append(got, &fasta.Fasta{Name: []byte(name), Sequence: seq})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sequences(FromFasta(%q))=%v, want %v", input, got, want)
+	}
+}
+
+// Reads all sequences from a fasta reader.
+func collect(r *fasta.Reader) ([]*fasta.Fasta, error) {
+	var result []*fasta.Fasta
+	for fa, err := range r.All() {
+		if err != nil {
+			return nil, err
+		}
+		if fa.Sequence == nil {
+			fa.Sequence = []byte{}
+		}
+		result = append(result, fa)
+	}
+	return result, nil
+}
+
+func TestSubsequence(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	seq := make([]byte, 1000)
+	for i := range seq {
+		seq[i] = "ACGTNacgtn"[rnd.IntN(10)]
+	}
+	r := fromFastaString(t, ">x\n"+string(seq))
+	for i := 0; i < 1000; i++ {
+		start := rnd.IntN(len(seq) + 1)
+		end := start + rnd.IntN(len(seq)-start+1)
+		got, err := r.Subsequence("x", start, end)
+		if err != nil {
+			t.Fatalf("Subsequence(x,%v,%v) failed: %v", start, end, err)
+		}
+		if want := seq[start:end]; !bytes.Equal(got, want) {
+			t.Fatalf("Subsequence(x,%v,%v)=%q, want %q", start, end, got,
+				want)
+		}
+	}
+
+	bad := [][2]int{{-1, 2}, {3, 2}, {0, 1001}}
+	for _, b := range bad {
+		if got, err := r.Subsequence("x", b[0], b[1]); err == nil {
+			t.Errorf("Subsequence(x,%v,%v)=%q, want error", b[0], b[1], got)
+		}
+	}
+	if got, err := r.Subsequence("y", 0, 1); err == nil {
+		t.Errorf("Subsequence(y,0,1)=%q, want error", got)
+	}
+}
+
+func TestWrite_iupac(t *testing.T) {
+	r := fromFastaString(t, ">x\nACRYgtkN\n")
+	got, err := r.Sequence("x")
+	if err != nil {
+		t.Fatalf("Sequence(x) failed: %v", err)
+	}
+	if want := "ACNNgtnN"; string(got) != want {
+		t.Errorf("Sequence(x)=%q, want %q", got, want)
+	}
+}
+
+func TestNewReader_bigEndian(t *testing.T) {
+	input := []byte{
+		0x1A, 0x41, 0x27, 0x43, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, // Header.
+		2, 'h', 'i', 0, 0, 0, 23, // Index.
+		0, 0, 0, 6, // Size.
+		0, 0, 0, 1, 0, 0, 0, 4, 0, 0, 0, 1, // N blocks.
+		0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, // Mask blocks.
+		0, 0, 0, 0, // Reserved.
+		0b10_01_11_00, 0b00_01_00_00, // ACGTTC
+	}
+	r, err := NewReader(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	got, err := r.Sequence("hi")
+	if err != nil {
+		t.Fatalf("Sequence(hi) failed: %v", err)
+	}
+	if want := "acGTNC"; string(got) != want {
+		t.Errorf("Sequence(hi)=%q, want %q", got, want)
+	}
+}
+
+func TestNewReader_bad(t *testing.T) {
+	inputs := [][]byte{
+		nil,
+		{1, 2, 3, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		{0x43, 0x27, 0x41, 0x1A, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		{0x43, 0x27, 0x41, 0x1A, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0},
+	}
+	for _, input := range inputs {
+		if _, err := NewReader(bytes.NewReader(input)); err == nil {
+			t.Errorf("NewReader(%v) succeeded, want error", input)
+		}
+	}
+}
+
+func TestWriter_duplicate(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{})
+	if err := w.Write("a", []byte("ACGT")); err != nil {
+		t.Fatalf("Write(a) failed: %v", err)
+	}
+	if err := w.Write("a", []byte("ACGT")); err == nil {
+		t.Errorf("Write(a) twice succeeded, want error")
+	}
+}
@@ -0,0 +1,154 @@
+package twobit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/fluhus/golgi/formats/fasta"
+)
+
+// Writer writes sequences to a .2bit stream. Since the index precedes the
+// sequences, sequences are kept in memory in packed form until Close is
+// called.
+type Writer struct {
+	w       io.Writer
+	names   []string
+	records [][]byte
+	seen    map[string]bool
+}
+
+// NewWriter returns a writer to the given stream.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, seen: map[string]bool{}}
+}
+
+// Write adds a sequence to the output. Bases other than ACGT (upper or
+// lowercase) are written as N.
+func (w *Writer) Write(name string, seq []byte) error {
+	if len(name) > math.MaxUint8 {
+		return fmt.Errorf("name is too long: %d, max %d", len(name),
+			math.MaxUint8)
+	}
+	if w.seen[name] {
+		return fmt.Errorf("duplicate sequence name: %q", name)
+	}
+	if len(seq) > math.MaxUint32 {
+		return fmt.Errorf("sequence %q is too long: %d", name, len(seq))
+	}
+	w.seen[name] = true
+	w.names = append(w.names, name)
+	w.records = append(w.records, packRecord(seq))
+	return nil
+}
+
+// Close writes the output. Does not close the underlying stream.
+func (w *Writer) Close() error {
+	// Version 1 has 64-bit offsets, for files over 4GB.
+	offset := int64(16)
+	for _, name := range w.names {
+		offset += int64(1 + len(name) + 4)
+	}
+	version, offsetSize := uint32(0), 4
+	size := offset
+	for _, rec := range w.records {
+		size += int64(len(rec))
+	}
+	if size > math.MaxUint32 {
+		version, offsetSize = 1, 8
+		offset += int64(len(w.names) * 4)
+	}
+
+	bw := bufio.NewWriter(w.w)
+	var header []byte
+	header = binary.LittleEndian.AppendUint32(header, signature)
+	header = binary.LittleEndian.AppendUint32(header, version)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(w.names)))
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	bw.Write(header)
+
+	for i, name := range w.names {
+		bw.WriteByte(byte(len(name)))
+		bw.WriteString(name)
+		var b []byte
+		if offsetSize == 4 {
+			b = binary.LittleEndian.AppendUint32(b, uint32(offset))
+		} else {
+			b = binary.LittleEndian.AppendUint64(b, uint64(offset))
+		}
+		bw.Write(b)
+		offset += int64(len(w.records[i]))
+	}
+	for _, rec := range w.records {
+		if _, err := bw.Write(rec); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Returns the record of a sequence: size, blocks and packed bases.
+func packRecord(seq []byte) []byte {
+	nBlks := findBlocks(seq, func(b byte) bool { return packed[b] == -1 })
+	mask := findBlocks(seq, func(b byte) bool { return 'a' <= b && b <= 'z' })
+
+	var result []byte
+	result = binary.LittleEndian.AppendUint32(result, uint32(len(seq)))
+	result = appendBlocks(result, nBlks)
+	result = appendBlocks(result, mask)
+	result = binary.LittleEndian.AppendUint32(result, 0) // Reserved.
+
+	for i := 0; i < len(seq); i += 4 {
+		var b byte
+		for j := i; j < i+4; j++ {
+			b <<= 2
+			if j < len(seq) && packed[seq[j]] != -1 {
+				b |= byte(packed[seq[j]])
+			}
+		}
+		result = append(result, b)
+	}
+	return result
+}
+
+// Appends the count, starts and sizes of the given blocks.
+func appendBlocks(b []byte, blocks []block) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(blocks)))
+	for _, blk := range blocks {
+		b = binary.LittleEndian.AppendUint32(b, uint32(blk.start))
+	}
+	for _, blk := range blocks {
+		b = binary.LittleEndian.AppendUint32(b, uint32(blk.end-blk.start))
+	}
+	return b
+}
+
+// Maps a base to its packed value, or -1 for bases that are written as N.
+var packed [256]int8
+
+func init() {
+	for i := range packed {
+		packed[i] = -1
+	}
+	for b, v := range map[byte]int8{'T': baseT, 'C': baseC, 'A': baseA,
+		'G': baseG} {
+		packed[b] = v
+		packed[b+'a'-'A'] = v
+	}
+}
+
+// FromFasta converts a fasta stream to .2bit.
+func FromFasta(w io.Writer, r *fasta.Reader) error {
+	tw := NewWriter(w)
+	for fa, err := range r.All() {
+		if err != nil {
+			return err
+		}
+		if err := tw.Write(string(fa.Name), fa.Sequence); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
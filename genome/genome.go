@@ -0,0 +1,210 @@
+// Package genome describes the chromosomes of a genome assembly.
+//
+// A Genome knows chromosome names and lengths, keeps them in a canonical
+// order, validates coordinates against them and resolves common naming
+// aliases ("chr1" and "1", "chrM" and "MT").
+//
+// Genomes can be loaded from .chrom.sizes files, .fai indexes or SAM @SQ
+// header lines.
+package genome
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Chrom is a single chromosome.
+type Chrom struct {
+	Name   string
+	Length int
+}
+
+// Genome holds chromosome lengths and order.
+type Genome struct {
+	chroms  []Chrom        // In canonical order.
+	index   map[string]int // Maps name to position in chroms.
+	aliases map[string]int // Maps explicit alias to position in chroms.
+}
+
+// New returns a genome with the given chromosomes, whose order is the
+// canonical order. Returns an error if names are duplicate or lengths are
+// negative.
+func New(chroms []Chrom) (*Genome, error) {
+	g := &Genome{nil, map[string]int{}, map[string]int{}}
+	for _, c := range chroms {
+		if c.Name == "" {
+			return nil, fmt.Errorf("empty chromosome name")
+		}
+		if c.Length < 0 {
+			return nil, fmt.Errorf("negative length for chromosome %q: %d",
+				c.Name, c.Length)
+		}
+		if _, ok := g.index[c.Name]; ok {
+			return nil, fmt.Errorf("duplicate chromosome: %q", c.Name)
+		}
+		g.index[c.Name] = len(g.chroms)
+		g.chroms = append(g.chroms, c)
+	}
+	return g, nil
+}
+
+// Chroms returns the chromosomes in canonical order. Modifying the slice does
+// not affect the genome.
+func (g *Genome) Chroms() []Chrom {
+	return append([]Chrom(nil), g.chroms...)
+}
+
+// AddAlias registers an alternative name for a chromosome in the genome.
+func (g *Genome) AddAlias(alias, name string) error {
+	i, ok := g.index[name]
+	if !ok {
+		return fmt.Errorf("no such chromosome: %q", name)
+	}
+	if j, ok := g.find(alias); ok && j != i {
+		return fmt.Errorf("alias %q already refers to %q", alias,
+			g.chroms[j].Name)
+	}
+	g.aliases[alias] = i
+	return nil
+}
+
+// Names of the mitochondrial chromosome in different conventions.
+var mitoNames = []string{"chrM", "MT", "chrMT", "M"}
+
+// Returns the position of the given chromosome in chroms, trying the exact
+// name, explicit aliases, and then the chr-prefix and mitochondrial
+// conventions.
+func (g *Genome) find(name string) (int, bool) {
+	if i, ok := g.index[name]; ok {
+		return i, true
+	}
+	if i, ok := g.aliases[name]; ok {
+		return i, true
+	}
+
+	// Mitochondria.
+	for _, m := range mitoNames {
+		if name == m {
+			for _, m := range mitoNames {
+				if i, ok := g.index[m]; ok {
+					return i, true
+				}
+			}
+			return 0, false
+		}
+	}
+
+	// With or without "chr".
+	if trimmed, ok := strings.CutPrefix(name, "chr"); ok {
+		i, ok := g.index[trimmed]
+		return i, ok
+	}
+	i, ok := g.index["chr"+name]
+	return i, ok
+}
+
+// Canonical returns the name by which the given chromosome appears in the
+// genome, resolving aliases. Returns false if not found.
+func (g *Genome) Canonical(name string) (string, bool) {
+	i, ok := g.find(name)
+	if !ok {
+		return "", false
+	}
+	return g.chroms[i].Name, true
+}
+
+// Length returns the length of the given chromosome, resolving aliases.
+// Returns false if not found.
+func (g *Genome) Length(name string) (int, bool) {
+	i, ok := g.find(name)
+	if !ok {
+		return 0, false
+	}
+	return g.chroms[i].Length, true
+}
+
+// Rank returns the position of the given chromosome in the canonical order,
+// resolving aliases. Returns -1 if not found.
+func (g *Genome) Rank(name string) int {
+	i, ok := g.find(name)
+	if !ok {
+		return -1
+	}
+	return i
+}
+
+// Less compares chromosomes by their canonical order. Chromosomes that are
+// not in the genome come after those that are, in natural order.
+func (g *Genome) Less(a, b string) bool {
+	ra, rb := g.Rank(a), g.Rank(b)
+	switch {
+	case ra != -1 && rb != -1:
+		return ra < rb
+	case ra != -1 || rb != -1:
+		return ra != -1
+	default:
+		return NaturalLess(a, b)
+	}
+}
+
+// Validate returns a non-nil error if the interval [start,end) is not within
+// the given chromosome.
+func (g *Genome) Validate(chr string, start, end int) error {
+	length, ok := g.Length(chr)
+	if !ok {
+		return fmt.Errorf("no such chromosome: %q", chr)
+	}
+	if start < 0 {
+		return fmt.Errorf("negative start position: %d", start)
+	}
+	if end < start {
+		return fmt.Errorf("end position %d is before start position %d",
+			end, start)
+	}
+	if end > length {
+		return fmt.Errorf("end position %d exceeds length of %s (%d)",
+			end, chr, length)
+	}
+	return nil
+}
+
+// NaturalLess compares chromosome names, treating runs of digits as numbers,
+// so that chr2 comes before chr10.
+func NaturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		ra, rb := nextRun(a), nextRun(b)
+		a, b = a[len(ra):], b[len(rb):]
+		if ra == rb {
+			continue
+		}
+		if isDigit(ra[0]) && isDigit(rb[0]) {
+			// Compare as numbers, ignoring leading zeros.
+			na, nb := strings.TrimLeft(ra, "0"), strings.TrimLeft(rb, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			return len(ra) < len(rb) // Fewer zeros first, for consistency.
+		}
+		return ra < rb
+	}
+	return len(a) < len(b)
+}
+
+// Returns the longest prefix of s that is all digits or all non-digits.
+// s should not be empty.
+func nextRun(s string) string {
+	digit := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+	return s[:i]
+}
+
+// Returns true iff b is an ASCII digit.
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,157 @@
+package genome
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/golgi/formats/sam"
+)
+
+func TestReadChromSizes(t *testing.T) {
+	input := "chr1\t1000\n# comment\nchr2\t500\n\nchrM\t16\n"
+	g, err := ReadChromSizes(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadChromSizes(%q) failed: %v", input, err)
+	}
+	want := []Chrom{{"chr1", 1000}, {"chr2", 500}, {"chrM", 16}}
+	if got := g.Chroms(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadChromSizes(%q)=%v, want %v", input, got, want)
+	}
+
+	bad := []string{"chr1\n", "chr1\tx\n", "chr1\t1\nchr1\t2\n", "chr1\t-1\n"}
+	for _, input := range bad {
+		if _, err := ReadChromSizes(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadChromSizes(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestReadFai(t *testing.T) {
+	input := "chr1\t1000\t6\t60\t61\nchr2\t500\t1030\t60\t61\n"
+	g, err := ReadFai(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadFai(%q) failed: %v", input, err)
+	}
+	want := []Chrom{{"chr1", 1000}, {"chr2", 500}}
+	if got := g.Chroms(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadFai(%q)=%v, want %v", input, got, want)
+	}
+}
+
+func TestReadSAMHeader(t *testing.T) {
+	input := "@HD\tVN:1.6\n@SQ\tSN:chr1\tLN:1000\n@SQ\tLN:500\tSN:chr2\n" +
+		"@PG\tID:foo\n" +
+		"r\t0\tchr1\t5\t30\t4M\t*\t0\t0\tAAAA\tFFFF\n"
+	r := sam.NewReader(bytes.NewBufferString(input))
+	g, err := ReadSAMHeader(r)
+	if err != nil {
+		t.Fatalf("ReadSAMHeader(%q) failed: %v", input, err)
+	}
+	want := []Chrom{{"chr1", 1000}, {"chr2", 500}}
+	if got := g.Chroms(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadSAMHeader(%q)=%v, want %v", input, got, want)
+	}
+	if s, err := r.Next(); err != nil || s.Qname != "r" {
+		t.Fatalf("Next() after ReadSAMHeader()=%v,%v, want r", s, err)
+	}
+
+	if _, err := FromSAMHeader([]string{"@SQ\tSN:chr1"}); err == nil {
+		t.Errorf("FromSAMHeader() without LN succeeded, want error")
+	}
+}
+
+func TestAliases(t *testing.T) {
+	g, err := New([]Chrom{{"chr1", 100}, {"chr2", 200}, {"chrM", 16},
+		{"22", 300}, {"X", 400}})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := g.AddAlias("NC_000001", "chr1"); err != nil {
+		t.Fatalf("AddAlias() failed: %v", err)
+	}
+	if err := g.AddAlias("foo", "chr3"); err == nil {
+		t.Errorf("AddAlias() to missing chromosome succeeded, want error")
+	}
+	if err := g.AddAlias("1", "chr2"); err == nil {
+		t.Errorf("AddAlias() of existing alias succeeded, want error")
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"chr1", "chr1"}, {"1", "chr1"}, {"NC_000001", "chr1"},
+		{"2", "chr2"}, {"MT", "chrM"}, {"M", "chrM"}, {"chrMT", "chrM"},
+		{"chr22", "22"}, {"chrX", "X"}, {"chr3", ""}, {"Y", ""},
+	}
+	for _, test := range tests {
+		got, ok := g.Canonical(test.name)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("Canonical(%q)=%q,%v, want %q", test.name, got, ok,
+				test.want)
+		}
+	}
+
+	if l, ok := g.Length("MT"); !ok || l != 16 {
+		t.Errorf("Length(MT)=%v,%v, want 16", l, ok)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	g, err := New([]Chrom{{"chr1", 100}})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	tests := []struct {
+		chr   string
+		start int
+		end   int
+		ok    bool
+	}{
+		{"chr1", 0, 100, true},
+		{"1", 10, 20, true},
+		{"chr1", 50, 50, true},
+		{"chr1", 0, 101, false},
+		{"chr1", -1, 10, false},
+		{"chr1", 20, 10, false},
+		{"chr2", 0, 10, false},
+	}
+	for _, test := range tests {
+		err := g.Validate(test.chr, test.start, test.end)
+		if (err == nil) != test.ok {
+			t.Errorf("Validate(%q,%v,%v)=%v, want ok=%v", test.chr,
+				test.start, test.end, err, test.ok)
+		}
+	}
+}
+
+func TestLess(t *testing.T) {
+	g, err := New([]Chrom{{"chrM", 16}, {"chr1", 100}, {"chr10", 100},
+		{"chr2", 100}})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	want := []string{"MT", "1", "chr10", "chr2", "chr3", "chrX"}
+	got := []string{"chrX", "chr2", "chr3", "1", "MT", "chr10"}
+	sort.Slice(got, func(i, j int) bool { return g.Less(got[i], got[j]) })
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sorted with Less: %v, want %v", got, want)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	want := []string{"1", "2", "10", "chr1", "chr1_random", "chr2", "chr2a",
+		"chr10", "chr22", "chrM", "chrUn_gl000220", "chrX", "chrY"}
+	got := append([]string(nil), want...)
+	rand.New(rand.NewSource(0)).Shuffle(len(got), func(i, j int) {
+		got[i], got[j] = got[j], got[i]
+	})
+	sort.Slice(got, func(i, j int) bool { return NaturalLess(got[i], got[j]) })
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sorted with NaturalLess: %v, want %v", got, want)
+	}
+}
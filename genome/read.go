@@ -0,0 +1,123 @@
+package genome
+
+// Loading genomes from files.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fluhus/golgi/formats/sam"
+)
+
+// ReadChromSizes reads a genome from a .chrom.sizes stream, where each line
+// has a chromosome name and length, separated by a tab. Empty lines and lines
+// starting with '#' are ignored.
+func ReadChromSizes(r io.Reader) (*Genome, error) {
+	return readTable(r, 2)
+}
+
+// ReadFai reads a genome from a fasta index (.fai) stream.
+func ReadFai(r io.Reader) (*Genome, error) {
+	return readTable(r, 5)
+}
+
+// Reads a tab-separated table whose first 2 columns are chromosome name and
+// length, and that has at least the given number of columns.
+func readTable(r io.Reader, minFields int) (*Genome, error) {
+	var chroms []Chrom
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < minFields {
+			return nil, fmt.Errorf("line %d: bad number of fields: %d, "+
+				"expected at least %d", line, len(fields), minFields)
+		}
+		length, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad length: %q", line, fields[1])
+		}
+		chroms = append(chroms, Chrom{fields[0], length})
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+	return New(chroms)
+}
+
+// FromSAMHeader creates a genome from the @SQ lines of a SAM header. Other
+// lines are ignored.
+func FromSAMHeader(lines []string) (*Genome, error) {
+	var chroms []Chrom
+	for _, line := range lines {
+		fields := strings.Split(line, "\t")
+		if fields[0] != "@SQ" {
+			continue
+		}
+		c := Chrom{Length: -1}
+		for _, f := range fields[1:] {
+			if name, ok := strings.CutPrefix(f, "SN:"); ok {
+				c.Name = name
+			}
+			if length, ok := strings.CutPrefix(f, "LN:"); ok {
+				var err error
+				c.Length, err = strconv.Atoi(length)
+				if err != nil {
+					return nil, fmt.Errorf("bad length in SAM header: %q", f)
+				}
+			}
+		}
+		if c.Name == "" || c.Length == -1 {
+			return nil, fmt.Errorf("@SQ line is missing SN or LN: %q", line)
+		}
+		chroms = append(chroms, c)
+	}
+	return New(chroms)
+}
+
+// ReadSAMHeader reads the header lines of the given SAM reader and creates a
+// genome from its @SQ lines. The reader can then be used for reading the
+// alignments.
+func ReadSAMHeader(r *sam.Reader) (*Genome, error) {
+	var lines []string
+	var line string
+	var err error
+	for line, err = r.NextHeader(); err == nil; line, err = r.NextHeader() {
+		lines = append(lines, line)
+	}
+	if err != io.EOF {
+		return nil, err
+	}
+	return FromSAMHeader(lines)
+}
+
+// Load reads a genome from the given file. Files ending with .fai are read as
+// fasta indexes, .sam as SAM headers and anything else as chrom sizes.
+func Load(file string) (*Genome, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var g *Genome
+	switch {
+	case strings.HasSuffix(file, ".fai"):
+		g, err = ReadFai(f)
+	case strings.HasSuffix(file, ".sam"):
+		g, err = ReadSAMHeader(sam.NewReader(f))
+	default:
+		g, err = ReadChromSizes(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return g, nil
+}
@@ -0,0 +1,273 @@
+package kmer
+
+import (
+	"fmt"
+	"iter"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// Counter counts k-mers of sequences.
+type Counter interface {
+	// Add counts the k-mers of a sequence.
+	Add(seq []byte)
+
+	// Count returns the number of times a k-mer was counted.
+	Count(x Kmer) int
+
+	// Len returns the number of distinct k-mers.
+	Len() int
+
+	// All returns an iterator over the distinct k-mers and their counts.
+	All() iter.Seq2[Kmer, int]
+}
+
+// Returns the k-mer iterator of the given settings.
+func kmers(seq []byte, k int, canonical bool) iter.Seq2[int, Kmer] {
+	if canonical {
+		return AllCanonical(seq, k)
+	}
+	return All(seq, k)
+}
+
+// ----- MAP COUNTER -----------------------------------------------------------
+
+// MapCounter counts k-mers in a hash map. Fast for random access and small
+// numbers of distinct k-mers.
+type MapCounter struct {
+	k         int
+	canonical bool
+	counts    map[Kmer]int
+}
+
+// NewMapCounter returns an empty counter of k-mers of length k. If canonical
+// is true, counts canonical k-mers.
+func NewMapCounter(k int, canonical bool) *MapCounter {
+	checkK(k)
+	return &MapCounter{k, canonical, map[Kmer]int{}}
+}
+
+// Add counts the k-mers of a sequence.
+func (c *MapCounter) Add(seq []byte) {
+	for _, x := range kmers(seq, c.k, c.canonical) {
+		c.counts[x]++
+	}
+}
+
+// Count returns the number of times a k-mer was counted.
+func (c *MapCounter) Count(x Kmer) int {
+	return c.counts[x]
+}
+
+// Len returns the number of distinct k-mers.
+func (c *MapCounter) Len() int {
+	return len(c.counts)
+}
+
+// All returns an iterator over the distinct k-mers and their counts, in no
+// particular order.
+func (c *MapCounter) All() iter.Seq2[Kmer, int] {
+	return func(yield func(Kmer, int) bool) {
+		for x, n := range c.counts {
+			if !yield(x, n) {
+				return
+			}
+		}
+	}
+}
+
+// Merge adds the counts of other to c.
+func (c *MapCounter) Merge(other Counter) {
+	for x, n := range other.All() {
+		c.counts[x] += n
+	}
+}
+
+// ----- ARRAY COUNTER ---------------------------------------------------------
+
+// ArrayCounter counts k-mers in sorted arrays. Uses less memory than
+// MapCounter for large numbers of distinct k-mers, and iterates in sorted
+// order.
+//
+// Added k-mers are buffered and sorted into the counts when the buffer is
+// full or when counts are queried.
+type ArrayCounter struct {
+	k         int
+	canonical bool
+	kmers     []Kmer // Distinct k-mers, sorted.
+	counts    []int  // Counts of kmers.
+	pending   []Kmer // Unsorted k-mers that were not yet counted.
+}
+
+// Maximal number of pending k-mers before compacting.
+const maxPending = 1 << 20
+
+// NewArrayCounter returns an empty counter of k-mers of length k. If
+// canonical is true, counts canonical k-mers.
+func NewArrayCounter(k int, canonical bool) *ArrayCounter {
+	checkK(k)
+	return &ArrayCounter{k: k, canonical: canonical}
+}
+
+// Add counts the k-mers of a sequence.
+func (c *ArrayCounter) Add(seq []byte) {
+	for _, x := range kmers(seq, c.k, c.canonical) {
+		c.pending = append(c.pending, x)
+		if len(c.pending) >= maxPending {
+			c.compact()
+		}
+	}
+}
+
+// Sorts pending k-mers into the counts.
+func (c *ArrayCounter) compact() {
+	if len(c.pending) == 0 {
+		return
+	}
+	slices.Sort(c.pending)
+	var kmers []Kmer
+	var counts []int
+	for i := 0; i < len(c.pending); {
+		j := i + 1
+		for j < len(c.pending) && c.pending[j] == c.pending[i] {
+			j++
+		}
+		kmers = append(kmers, c.pending[i])
+		counts = append(counts, j-i)
+		i = j
+	}
+	c.pending = c.pending[:0]
+	c.kmers, c.counts = mergeSorted(c.kmers, c.counts, kmers, counts)
+}
+
+// Merges two sorted count arrays, summing counts of shared k-mers.
+func mergeSorted(k1 []Kmer, c1 []int, k2 []Kmer, c2 []int) ([]Kmer, []int) {
+	if len(k1) == 0 {
+		return k2, c2
+	}
+	if len(k2) == 0 {
+		return k1, c1
+	}
+	kmers := make([]Kmer, 0, len(k1)+len(k2))
+	counts := make([]int, 0, len(k1)+len(k2))
+	i, j := 0, 0
+	for i < len(k1) && j < len(k2) {
+		switch {
+		case k1[i] < k2[j]:
+			kmers, counts = append(kmers, k1[i]), append(counts, c1[i])
+			i++
+		case k1[i] > k2[j]:
+			kmers, counts = append(kmers, k2[j]), append(counts, c2[j])
+			j++
+		default:
+			kmers, counts = append(kmers, k1[i]), append(counts, c1[i]+c2[j])
+			i++
+			j++
+		}
+	}
+	kmers, counts = append(kmers, k1[i:]...), append(counts, c1[i:]...)
+	kmers, counts = append(kmers, k2[j:]...), append(counts, c2[j:]...)
+	return slices.Clip(kmers), slices.Clip(counts)
+}
+
+// Count returns the number of times a k-mer was counted.
+func (c *ArrayCounter) Count(x Kmer) int {
+	c.compact()
+	i, ok := slices.BinarySearch(c.kmers, x)
+	if !ok {
+		return 0
+	}
+	return c.counts[i]
+}
+
+// Len returns the number of distinct k-mers.
+func (c *ArrayCounter) Len() int {
+	c.compact()
+	return len(c.kmers)
+}
+
+// All returns an iterator over the distinct k-mers and their counts, in
+// ascending order of k-mers.
+func (c *ArrayCounter) All() iter.Seq2[Kmer, int] {
+	c.compact()
+	return func(yield func(Kmer, int) bool) {
+		for i, x := range c.kmers {
+			if !yield(x, c.counts[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Merge adds the counts of other to c. other is not modified. Panics if the
+// counters have different settings.
+func (c *ArrayCounter) Merge(other *ArrayCounter) {
+	if c.k != other.k || c.canonical != other.canonical {
+		panic(fmt.Sprintf("merging counters of k=%d,canonical=%v and "+
+			"k=%d,canonical=%v", c.k, c.canonical, other.k, other.canonical))
+	}
+	c.compact()
+	other.compact()
+	c.kmers, c.counts = mergeSorted(c.kmers, c.counts, other.kmers,
+		other.counts)
+}
+
+// ----- PARALLEL COUNTING -----------------------------------------------------
+
+// CountAll counts the k-mers of the given sequences using the given number
+// of goroutines, or GOMAXPROCS if workers is 0. Each goroutine counts into
+// its own counter, and the counters are merged in parallel at the end.
+func CountAll(seqs iter.Seq[[]byte], k int, canonical bool,
+	workers int) *ArrayCounter {
+	checkK(k)
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	ch := make(chan []byte, workers)
+	counters := make([]*ArrayCounter, workers)
+	var wg sync.WaitGroup
+	for i := range counters {
+		counters[i] = NewArrayCounter(k, canonical)
+		wg.Add(1)
+		go func(c *ArrayCounter) {
+			defer wg.Done()
+			for seq := range ch {
+				c.Add(seq)
+			}
+			c.compact()
+		}(counters[i])
+	}
+	for seq := range seqs {
+		ch <- seq
+	}
+	close(ch)
+	wg.Wait()
+	return MergeAll(counters)
+}
+
+// MergeAll merges the given counters into one, merging pairs in parallel.
+// The input counters should not be used afterwards.
+func MergeAll(counters []*ArrayCounter) *ArrayCounter {
+	if len(counters) == 0 {
+		return nil
+	}
+	for len(counters) > 1 {
+		var wg sync.WaitGroup
+		for i := 0; i+1 < len(counters); i += 2 {
+			wg.Add(1)
+			go func(a, b *ArrayCounter) {
+				defer wg.Done()
+				a.Merge(b)
+			}(counters[i], counters[i+1])
+		}
+		wg.Wait()
+		next := counters[:0]
+		for i := 0; i < len(counters); i += 2 {
+			next = append(next, counters[i])
+		}
+		counters = next
+	}
+	return counters[0]
+}
@@ -0,0 +1,135 @@
+// Package kmer extracts and counts k-mers of DNA sequences.
+//
+// A k-mer of up to 32 bases is packed in a uint64, 2 bits per base, with the
+// first base most significant. Bases are encoded like in sequtil.Ntoi:
+// A=0, C=1, G=2, T=3. K-mers that contain other characters are skipped.
+//
+//	c := kmer.NewMapCounter(21, true)
+//	c.Add(seq)
+//	for x, n := range c.All() {
+//		fmt.Println(kmer.String(x, 21), n)
+//	}
+package kmer
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/fluhus/golgi/sequtil"
+)
+
+// MaxK is the maximal supported k.
+const MaxK = 32
+
+// Kmer is a packed k-mer. The value of k is not stored.
+type Kmer uint64
+
+// Returns a mask of the lowest 2k bits.
+func mask(k int) Kmer {
+	if k == MaxK {
+		return ^Kmer(0)
+	}
+	return Kmer(1)<<(2*k) - 1
+}
+
+// Panics if k is out of range.
+func checkK(k int) {
+	if k < 1 || k > MaxK {
+		panic(fmt.Sprintf("bad k: %d, want 1-%d", k, MaxK))
+	}
+}
+
+// Encode returns the k-mer of the given sequence, whose length is k. Returns
+// an error if the sequence is too long or has characters other than ACGT.
+func Encode(seq []byte) (Kmer, error) {
+	if len(seq) == 0 || len(seq) > MaxK {
+		return 0, fmt.Errorf("bad k-mer length: %d, want 1-%d", len(seq),
+			MaxK)
+	}
+	var x Kmer
+	for i, b := range seq {
+		v := sequtil.Ntoi(b)
+		if v == -1 {
+			return 0, &sequtil.InvalidBaseError{Pos: i, Base: b}
+		}
+		x = x<<2 | Kmer(v)
+	}
+	return x, nil
+}
+
+// Decode writes the bases of a k-mer to dst, which should have length k.
+func Decode(dst []byte, x Kmer) {
+	for i := len(dst) - 1; i >= 0; i-- {
+		dst[i] = sequtil.Iton(int(x & 3))
+		x >>= 2
+	}
+}
+
+// String returns the bases of a k-mer.
+func String(x Kmer, k int) string {
+	checkK(k)
+	b := make([]byte, k)
+	Decode(b, x)
+	return string(b)
+}
+
+// ReverseComplement returns the reverse complement of a k-mer.
+func ReverseComplement(x Kmer, k int) Kmer {
+	checkK(k)
+	// Complement, then reverse the order of 2-bit groups.
+	x = ^x
+	x = (x>>2)&0x3333333333333333 | (x&0x3333333333333333)<<2
+	x = (x>>4)&0x0F0F0F0F0F0F0F0F | (x&0x0F0F0F0F0F0F0F0F)<<4
+	x = (x>>8)&0x00FF00FF00FF00FF | (x&0x00FF00FF00FF00FF)<<8
+	x = (x>>16)&0x0000FFFF0000FFFF | (x&0x0000FFFF0000FFFF)<<16
+	x = x>>32 | x<<32
+	return x >> (2 * (MaxK - k))
+}
+
+// Canonical returns the minimum of a k-mer and its reverse complement.
+func Canonical(x Kmer, k int) Kmer {
+	return min(x, ReverseComplement(x, k))
+}
+
+// All returns an iterator over the k-mers of seq and their positions.
+// K-mers that contain characters other than ACGT are skipped. Panics if k is
+// not in 1-32.
+func All(seq []byte, k int) iter.Seq2[int, Kmer] {
+	return rolling(seq, k, false)
+}
+
+// AllCanonical is like All, but yields canonical k-mers.
+func AllCanonical(seq []byte, k int) iter.Seq2[int, Kmer] {
+	return rolling(seq, k, true)
+}
+
+// Implements All and AllCanonical, updating the forward and reverse
+// complement k-mers with each base.
+func rolling(seq []byte, k int, canonical bool) iter.Seq2[int, Kmer] {
+	checkK(k)
+	m := mask(k)
+	shift := 2 * (k - 1)
+	return func(yield func(int, Kmer) bool) {
+		var fwd, rev Kmer
+		valid := 0 // Number of valid bases at the end of the current k-mer.
+		for i, b := range seq {
+			v := sequtil.Ntoi(b)
+			if v == -1 {
+				valid = 0
+				continue
+			}
+			fwd = (fwd<<2 | Kmer(v)) & m
+			rev = rev>>2 | Kmer(3-v)<<shift
+			if valid++; valid < k {
+				continue
+			}
+			x := fwd
+			if canonical {
+				x = min(fwd, rev)
+			}
+			if !yield(i-k+1, x) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package kmer
+
+import (
+	"maps"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/fluhus/golgi/sequtil"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Kmer
+	}{
+		{"A", 0},
+		{"T", 3},
+		{"ACGT", 0b00011011},
+		{"acgt", 0b00011011},
+		{"TTTTTTTTTTTTTTTTTTTTTTTTTTTTTTTT", ^Kmer(0)},
+	}
+	for _, test := range tests {
+		got, err := Encode([]byte(test.input))
+		if err != nil {
+			t.Fatalf("Encode(%q) failed: %v", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("Encode(%q)=%v, want %v", test.input, got, test.want)
+		}
+		if s := String(got, len(test.input)); s != upper(test.input) {
+			t.Errorf("String(%v)=%q, want %q", got, s, test.input)
+		}
+	}
+
+	bad := []string{"", "ACNT", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"}
+	for _, input := range bad {
+		if got, err := Encode([]byte(input)); err == nil {
+			t.Errorf("Encode(%q)=%v, want error", input, got)
+		}
+	}
+}
+
+// Returns s in uppercase.
+func upper(s string) string {
+	b := []byte(s)
+	for i := range b {
+		b[i] = sequtil.Iton(sequtil.Ntoi(b[i]))
+	}
+	return string(b)
+}
+
+func TestReverseComplement(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for k := 1; k <= MaxK; k++ {
+		seq := make([]byte, k)
+		for i := range seq {
+			seq[i] = "ACGT"[rnd.IntN(4)]
+		}
+		x, _ := Encode(seq)
+		want := sequtil.ReverseComplementString(string(seq))
+		if got := String(ReverseComplement(x, k), k); got != want {
+			t.Errorf("ReverseComplement(%s)=%s, want %s", seq, got, want)
+		}
+		if got, want := Canonical(x, k), min(x,
+			ReverseComplement(x, k)); got != want {
+			t.Errorf("Canonical(%s)=%v, want %v", seq, got, want)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	seq := []byte("ACGTNAACGTTAg")
+	type pair struct {
+		pos  int
+		kmer string
+	}
+	tests := []struct {
+		k         int
+		canonical bool
+		want      []pair
+	}{
+		{3, false, []pair{{0, "ACG"}, {1, "CGT"}, {5, "AAC"}, {6, "ACG"},
+			{7, "CGT"}, {8, "GTT"}, {9, "TTA"}, {10, "TAG"}}},
+		{3, true, []pair{{0, "ACG"}, {1, "ACG"}, {5, "AAC"}, {6, "ACG"},
+			{7, "ACG"}, {8, "AAC"}, {9, "TAA"}, {10, "CTA"}}},
+		{5, false, []pair{{5, "AACGT"}, {6, "ACGTT"}, {7, "CGTTA"},
+			{8, "GTTAG"}}},
+		{9, false, nil},
+	}
+	for _, test := range tests {
+		var got []pair
+		for pos, x := range kmers(seq, test.k, test.canonical) {
+			got = append(got, pair{pos, String(x, test.k)})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("All(%s,%v,canonical=%v)=%v, want %v", seq, test.k,
+				test.canonical, got, test.want)
+		}
+	}
+}
+
+func TestAll_k32(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	seq := make([]byte, 100)
+	for i := range seq {
+		seq[i] = "ACGT"[rnd.IntN(4)]
+	}
+	for pos, x := range AllCanonical(seq, 32) {
+		fwd, _ := Encode(seq[pos : pos+32])
+		if want := Canonical(fwd, 32); x != want {
+			t.Fatalf("AllCanonical(...)[%v]=%v, want %v", pos, x, want)
+		}
+	}
+}
+
+func TestCounters(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	var seqs [][]byte
+	for i := 0; i < 50; i++ {
+		seq := make([]byte, rnd.IntN(200))
+		for j := range seq {
+			seq[j] = "ACGTN"[rnd.IntN(5)]
+		}
+		seqs = append(seqs, seq)
+	}
+
+	for _, canonical := range []bool{false, true} {
+		want := map[Kmer]int{}
+		for _, seq := range seqs {
+			for _, x := range kmers(seq, 5, canonical) {
+				want[x]++
+			}
+		}
+
+		m := NewMapCounter(5, canonical)
+		a := NewArrayCounter(5, canonical)
+		for _, seq := range seqs {
+			m.Add(seq)
+			a.Add(seq)
+		}
+		par := CountAll(slices.Values(seqs), 5, canonical, 3)
+		for _, c := range []Counter{m, a, par} {
+			got := maps.Collect(c.All())
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%T(canonical=%v) counts=%v, want %v", c, canonical,
+					got, want)
+			}
+			if c.Len() != len(want) {
+				t.Errorf("%T.Len()=%v, want %v", c, c.Len(), len(want))
+			}
+			for x, n := range want {
+				if got := c.Count(x); got != n {
+					t.Errorf("%T.Count(%v)=%v, want %v", c, x, got, n)
+				}
+			}
+		}
+
+		var keys []Kmer
+		for x := range a.All() {
+			keys = append(keys, x)
+		}
+		if !slices.IsSorted(keys) {
+			t.Errorf("ArrayCounter.All() is not sorted")
+		}
+	}
+}
+
+func TestMergeAll(t *testing.T) {
+	var counters []*ArrayCounter
+	for _, seq := range []string{"AAAA", "AAAC", "AAA", "CCCC", "AAAA"} {
+		c := NewArrayCounter(3, false)
+		c.Add([]byte(seq))
+		counters = append(counters, c)
+	}
+	got := maps.Collect(MergeAll(counters).All())
+	want := map[Kmer]int{0: 6, 1: 1, 0b010101: 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeAll()=%v, want %v", got, want)
+	}
+}
+
+func BenchmarkCounters(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	seq := make([]byte, 1000000)
+	for i := range seq {
+		seq[i] = "ACGT"[rnd.IntN(4)]
+	}
+	b.Run("map", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewMapCounter(21, true).Add(seq)
+		}
+	})
+	b.Run("array", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewArrayCounter(21, true).Add(seq)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+// Package records provides generic iteration over genomic records.
+//
+// Every reader in the formats packages has an All method that returns an
+// iter.Seq2 of records and errors. The functions here compose such iterators
+// the same way regardless of format:
+//
+//	r := bed.NewScanner(f)
+//	long := records.Filter(r.All(), func(b *bed.Bed) bool {
+//		return b.End-b.Start >= 100
+//	})
+//	for batch, err := range records.Batch(long, 1000) {
+//		...
+//	}
+//
+// Iterators stop after yielding an error. A record yielded together with a
+// non-nil error should be ignored.
+package records
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// Returns an iterator over the given records.
+func Slice[T any](a []T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, x := range a {
+			if !yield(x, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Returns all the records of the given iterator, or the first error.
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var result []T
+	for x, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, x)
+	}
+	return result, nil
+}
+
+// Returns an iterator over the records for which f returns true.
+func Filter[T any](seq iter.Seq2[T, error],
+	f func(T) bool) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for x, err := range seq {
+			if err != nil {
+				yield(x, err)
+				return
+			}
+			if f(x) && !yield(x, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over the results of f on each record.
+func Map[T, U any](seq iter.Seq2[T, error], f func(T) U) iter.Seq2[U, error] {
+	return func(yield func(U, error) bool) {
+		for x, err := range seq {
+			if err != nil {
+				var zero U
+				yield(zero, err)
+				return
+			}
+			if !yield(f(x), nil) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over the results of f on each record. Stops at the
+// first error returned by f.
+func MapErr[T, U any](seq iter.Seq2[T, error],
+	f func(T) (U, error)) iter.Seq2[U, error] {
+	return func(yield func(U, error) bool) {
+		for x, err := range seq {
+			if err != nil {
+				var zero U
+				yield(zero, err)
+				return
+			}
+			y, err := f(x)
+			if !yield(y, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over slices of n consecutive records. The last slice
+// may be shorter. Records that were read before an error are yielded before
+// it. Panics if n is not positive.
+func Batch[T any](seq iter.Seq2[T, error], n int) iter.Seq2[[]T, error] {
+	if n < 1 {
+		panic("batch size must be positive")
+	}
+
+	return func(yield func([]T, error) bool) {
+		batch := make([]T, 0, n)
+		for x, err := range seq {
+			if err != nil {
+				if len(batch) > 0 && !yield(batch, nil) {
+					return
+				}
+				yield(nil, err)
+				return
+			}
+			batch = append(batch, x)
+			if len(batch) == n {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = make([]T, 0, n)
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
+
+// Returns an iterator that merges the given sorted iterators into one sorted
+// sequence. less should return true iff a comes before b. Records that are
+// equal keep the order of their iterators.
+func MergeSorted[T any](less func(a, b T) bool,
+	seqs ...iter.Seq2[T, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		h := &mergeHeap[T]{less: less}
+		defer func() {
+			for _, s := range h.heads {
+				s.stop()
+			}
+		}()
+
+		// Pull the first record of each iterator.
+		for i, seq := range seqs {
+			next, stop := iter.Pull2(seq)
+			x, err, ok := next()
+			if !ok {
+				stop()
+				continue
+			}
+			if err != nil {
+				stop()
+				yield(x, err)
+				return
+			}
+			h.heads = append(h.heads, &mergeHead[T]{x, i, next, stop})
+		}
+		heap.Init(h)
+
+		// Yield the smallest and replace it.
+		for len(h.heads) > 0 {
+			head := h.heads[0]
+			if !yield(head.x, nil) {
+				return
+			}
+			x, err, ok := head.next()
+			if !ok {
+				head.stop()
+				heap.Pop(h)
+				continue
+			}
+			if err != nil {
+				yield(x, err)
+				return
+			}
+			head.x = x
+			heap.Fix(h, 0)
+		}
+	}
+}
+
+// The current record of one of the merged iterators.
+type mergeHead[T any] struct {
+	x    T                       // Current record.
+	i    int                     // Index of the iterator, for stability.
+	next func() (T, error, bool) // Pulls the next record.
+	stop func()                  // Releases the iterator.
+}
+
+// A min-heap of merged iterators, by their current records.
+type mergeHeap[T any] struct {
+	heads []*mergeHead[T]
+	less  func(a, b T) bool
+}
+
+func (h *mergeHeap[T]) Len() int {
+	return len(h.heads)
+}
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	a, b := h.heads[i], h.heads[j]
+	if h.less(a.x, b.x) {
+		return true
+	}
+	if h.less(b.x, a.x) {
+		return false
+	}
+	return a.i < b.i
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) {
+	h.heads[i], h.heads[j] = h.heads[j], h.heads[i]
+}
+
+func (h *mergeHeap[T]) Push(x any) {
+	h.heads = append(h.heads, x.(*mergeHead[T]))
+}
+
+func (h *mergeHeap[T]) Pop() any {
+	x := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return x
+}
@@ -0,0 +1,142 @@
+package records
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/golgi/formats/bed"
+)
+
+func TestFilterMap(t *testing.T) {
+	input := "chr1\t10\t20\nchr1\t30\t300\nchr2\t5\t500\nchr3\t1\t2\n"
+	seq := bed.NewScanner(strings.NewReader(input)).All()
+	long := Filter(seq, func(b *bed.Bed) bool {
+		return b.End-b.Start >= 100
+	})
+	got, err := Collect(Map(long, func(b *bed.Bed) string {
+		return b.Chr
+	}))
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+	if want := []string{"chr1", "chr2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Collect()=%v, want %v", got, want)
+	}
+}
+
+func TestMapErr(t *testing.T) {
+	seq := MapErr(Slice([]int{1, 2, 3, 4}), func(i int) (int, error) {
+		if i == 3 {
+			return 0, fmt.Errorf("bad number: %v", i)
+		}
+		return i * 10, nil
+	})
+	var got []int
+	var gotErr error
+	for x, err := range seq {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		got = append(got, x)
+	}
+	if want := []int{10, 20}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapErr()=%v, want %v", got, want)
+	}
+	if gotErr == nil {
+		t.Errorf("MapErr() succeeded, want error")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		input []int
+		n     int
+		want  [][]int
+	}{
+		{nil, 2, nil},
+		{[]int{1}, 2, [][]int{{1}}},
+		{[]int{1, 2, 3, 4}, 2, [][]int{{1, 2}, {3, 4}}},
+		{[]int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{[]int{1, 2, 3}, 5, [][]int{{1, 2, 3}}},
+	}
+	for _, test := range tests {
+		got, err := Collect(Batch(Slice(test.input), test.n))
+		if err != nil {
+			t.Fatalf("Batch(%v,%v) failed: %v", test.input, test.n, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Batch(%v,%v)=%v, want %v",
+				test.input, test.n, got, test.want)
+		}
+	}
+}
+
+func TestBatch_error(t *testing.T) {
+	input := "chr1\t10\t20\nchr1\t30\t40\nchr1\tx\t40\n"
+	seq := bed.NewScanner(strings.NewReader(input)).All()
+	var got [][]*bed.Bed
+	var gotErr error
+	for batch, err := range Batch(seq, 5) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		got = append(got, batch)
+	}
+	if len(got) != 1 || len(got[0]) != 2 {
+		t.Errorf("Batch()=%v, want 1 batch of 2", got)
+	}
+	if gotErr == nil {
+		t.Errorf("Batch() succeeded, want error")
+	}
+}
+
+func TestMergeSorted(t *testing.T) {
+	type pair struct {
+		key   int
+		input int
+	}
+	less := func(a, b pair) bool { return a.key < b.key }
+	got, err := Collect(MergeSorted(less,
+		Slice([]pair{{1, 0}, {4, 0}, {4, 0}, {9, 0}}),
+		Slice([]pair{}),
+		Slice([]pair{{0, 2}, {4, 2}, {10, 2}}),
+		Slice([]pair{{2, 3}, {3, 3}}),
+	))
+	if err != nil {
+		t.Fatalf("MergeSorted() failed: %v", err)
+	}
+	want := []pair{{0, 2}, {1, 0}, {2, 3}, {3, 3}, {4, 0}, {4, 0}, {4, 2},
+		{9, 0}, {10, 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MergeSorted()=%v, want %v", got, want)
+	}
+}
+
+func TestMergeSorted_earlyStop(t *testing.T) {
+	stopped := 0
+	counting := func(a []int) iter.Seq2[int, error] {
+		return func(yield func(int, error) bool) {
+			defer func() { stopped++ }()
+			for _, x := range a {
+				if !yield(x, nil) {
+					return
+				}
+			}
+		}
+	}
+	less := func(a, b int) bool { return a < b }
+	for x := range MergeSorted(less, counting([]int{1, 3}),
+		counting([]int{2, 4})) {
+		if x == 2 {
+			break
+		}
+	}
+	if stopped != 2 {
+		t.Fatalf("%v iterators were stopped, want 2", stopped)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"sort"
 	"strings"
 
 	"github.com/fluhus/golgi/formats/bed"
@@ -24,17 +23,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	fmt.Println("Reading events...")
-	e, err := eventsFromFile(args.eventFile)
+	fmt.Println("Reading and indexing events...")
+	idx, err := indexFile(args.eventFile, args.extend)
 
 	if err != nil {
 		fmt.Println("Error:", err)
 		os.Exit(2)
 	}
 
-	fmt.Println("Indexing...")
-	idx := e.index()
-
 	fmt.Println("Reading regions...")
 	err = processFile(args.inFile, args.outFile, idx, args.prior)
 
@@ -46,32 +42,11 @@ func main() {
 	fmt.Println("Done!")
 }
 
-// ***** EVENT PARSING ********************************************************
-
-type event struct {
-	chr   string
-	pos   int
-	start bool // true if event start, false if event end
-	name  string
-}
-
-type events []*event
-
-func (e events) names() []string {
-	m := make(map[string]struct{})
-	for _, evt := range e {
-		m[evt.name] = struct{}{}
-	}
-
-	var result []string
-	for name := range m {
-		result = append(result, name)
-	}
-
-	return result
-}
+// ***** EVENT INDEXING *******************************************************
 
-func eventsFromFile(file string) (events, error) {
+// Reads events from the given bed file and indexes them. Each event is
+// extended by the given number of bases in each direction.
+func indexFile(file string, extend int) (bed.Index, error) {
 	f, err := os.Open(file)
 	if err != nil {
 		return nil, err
@@ -79,164 +54,28 @@ func eventsFromFile(file string) (events, error) {
 	defer f.Close()
 
 	scanner := bed.NewScanner(f)
-	var result events
+	builder := bed.NewIndexBuilder()
 
 	for scanner.Scan() {
+		// Only the name is used, so extra columns may have any format.
 		b := scanner.Bed()
-		name := scanner.Fields()[0]
-		result = append(result, &event{b.Chr, b.Start, true, name})
-		result = append(result, &event{b.Chr, b.End, false, name})
+		p := &bed.Payload{Strand: '.', Bed: b, Fields: scanner.Fields()}
+		if len(p.Fields) > 0 {
+			p.Name = p.Fields[0]
+		}
+		builder.AddPayload(b.Chr, max(b.Start-extend, 0), b.End+extend, p)
 	}
 
 	if scanner.Err() != nil {
 		return nil, scanner.Err()
 	}
 
-	// Sort
-	result.sort()
-
-	return result, nil
-}
-
-// ***** EVENT SORTING ********************************************************
-
-func (e events) sort() {
-	sort.Sort(e)
-}
-
-func (e events) Len() int {
-	return len(e)
-}
-
-func (e events) Less(i, j int) bool {
-	if e[i].chr != e[j].chr {
-		return e[i].chr < e[j].chr
-	}
-
-	if e[i].pos != e[j].pos {
-		return e[i].pos < e[j].pos
-	}
-
-	if e[i].start != e[j].start {
-		return e[i].start == false // end comes first
-	}
-
-	return true // arbitrary
-}
-
-func (e events) Swap(i, j int) {
-	e[i], e[j] = e[j], e[i]
-}
-
-// ***** EVENT INDEXING *******************************************************
-
-// Maps from event name to count.
-type eventCounter map[string]int
-
-// Holds names of events.
-type eventSet map[string]struct{}
-
-// Holds event names that are present starting from pos up to the next set.
-type eventSetPos struct {
-	chr   string
-	pos   int
-	names eventSet
-}
-
-type index []*eventSetPos
-
-// Events are assumed to be sorted.
-func (e events) index() index {
-	var result []*eventSetPos
-	var counter eventCounter
-
-	for _, evt := range e {
-		// Check if new chromosome
-		if len(result) == 0 || result[len(result)-1].chr != evt.chr {
-			// Reset counter
-			counter = make(map[string]int)
-		}
-
-		// Start -> increment
-		if evt.start {
-			counter[evt.name]++
-
-			// If created new event
-			if counter[evt.name] == 1 {
-				result = append(result, &eventSetPos{evt.chr, evt.pos,
-					counter.set()})
-			}
-
-			// End -> decrement
-		} else {
-			counter[evt.name]--
-
-			// If deleted an event
-			if counter[evt.name] == 0 {
-				result = append(result, &eventSetPos{evt.chr, evt.pos,
-					counter.set()})
-			}
-
-			// If negative, we have a problem
-			if counter[evt.name] == -1 {
-				panic(fmt.Sprintf("-1 event count at (%s,%d): %s",
-					evt.chr, evt.pos, evt.name))
-			}
-		}
-	}
-
-	return result
-}
-
-func (e eventCounter) set() eventSet {
-	result := make(map[string]struct{})
-
-	for name := range e {
-		if e[name] > 0 {
-			result[name] = struct{}{}
-		}
-	}
-
-	return result
-}
-
-// Returns the names of all events that overlap
-func (idx index) search(chr string, start int, end int) eventSet {
-	// Search
-	i := sort.Search(len(idx), func(a int) bool {
-		return idx[a].chr > chr || (idx[a].chr == chr && idx[a].pos > start)
-	}) - 1
-
-	if i == -1 {
-		i = 0
-	}
-
-	result := make(map[string]struct{})
-
-	for ; idx[i].chr < chr || (idx[i].chr == chr && idx[i].pos <= end); i++ {
-		if idx[i].chr != chr {
-			continue
-		}
-
-		for name := range idx[i].names {
-			result[name] = struct{}{}
-		}
-	}
-
-	return result
-}
-
-// Returns one arbitrary event from the set. If empty, returns an empty string.
-func (e eventSet) event() string {
-	for name := range e {
-		return name
-	}
-	return ""
+	return builder.Build(), nil
 }
 
 // ***** REGION FILE PROCESSING ***********************************************
 
-func processFile(in string, out string, idx index, prior []string) error {
+func processFile(in string, out string, idx bed.Index, prior []string) error {
 	// Buffered i/o.
 	var bout *bufio.Writer
 	var scanner *bed.Scanner
@@ -269,24 +108,13 @@ func processFile(in string, out string, idx index, prior []string) error {
 	defer bout.Flush()
 
 	// Iterate over lines
+	byPrior := bed.ByNames(prior...)
 	for scanner.Scan() {
-		// Look up
+		// Look up, picking by priority
 		b := scanner.Bed()
-		eSet := idx.search(b.Chr, b.Start, b.End)
 		var name string
-		found := false
-
-		// Pick by priority
-		for _, p := range prior {
-			if _, ok := eSet[p]; ok {
-				name = p
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			name = eSet.event()
+		if p := idx.Resolve(b.Chr, b.Start, b.End, byPrior); p != nil {
+			name = p.Name
 		}
 
 		// Print
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fluhus/golgi/formats/bed"
+	"github.com/fluhus/golgi/formats/fasta"
+	"github.com/fluhus/golgi/genome"
+	"github.com/fluhus/golgi/sequtil"
+)
+
+func main() {
+	// Parse arguments
+	err := parseArgs()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing arguments:", err)
+		os.Exit(1)
+	}
+	if args.help {
+		fmt.Fprintln(os.Stderr, help)
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	fmt.Fprintln(os.Stderr, "Opening fasta...")
+	ref, err := openReference(args.fastaFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	defer ref.f.Close()
+
+	fmt.Fprintln(os.Stderr, "Annotating regions...")
+	err = processFile(args.inFile, args.outFile, ref, args.extend)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+
+	fmt.Fprintln(os.Stderr, "Done!")
+}
+
+// ***** REFERENCE ************************************************************
+
+// An indexed fasta with its chromosome names.
+type reference struct {
+	f   *fasta.IndexedFasta
+	gen *genome.Genome
+}
+
+// Opens an indexed fasta file.
+func openReference(file string) (*reference, error) {
+	f, err := fasta.OpenIndexed(file)
+	if err != nil {
+		return nil, err
+	}
+	chroms := make([]genome.Chrom, len(f.Entries()))
+	for i, e := range f.Entries() {
+		chroms[i] = genome.Chrom{Name: e.Name, Length: e.Length}
+	}
+	gen, err := genome.New(chroms)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &reference{f, gen}, nil
+}
+
+// Returns the sequence of the given region, clipped to the chromosome.
+// Chromosome aliases like 1 for chr1 are allowed.
+func (r *reference) sequence(chr string, start, end int) ([]byte, error) {
+	name, ok := r.gen.Canonical(chr)
+	if !ok {
+		return nil, fmt.Errorf("no such chromosome: %q", chr)
+	}
+	length, _ := r.gen.Length(name)
+	start, end = max(start, 0), min(end, length)
+	if end < start {
+		return nil, fmt.Errorf("region %s:%d-%d is outside the chromosome",
+			chr, start, end)
+	}
+	return r.f.Subsequence(name, start, end)
+}
+
+// ***** REGION FILE PROCESSING ***********************************************
+
+// Statistics of the output columns, in order.
+var stats = []func([]byte) float64{sequtil.GC, sequtil.CpGRatio,
+	sequtil.Entropy, sequtil.DUST}
+
+// Reads regions from the input bed file and writes them with their
+// statistics. Each region is extended by the given number of bases in each
+// direction before computing statistics.
+func processFile(in string, out string, ref *reference, extend int) error {
+	// Buffered i/o.
+	var bout *bufio.Writer
+	var scanner *bed.Scanner
+
+	// Open files
+	if in == "" {
+		scanner = bed.NewScanner(os.Stdin)
+	} else {
+		fin, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer fin.Close()
+
+		scanner = bed.NewScanner(fin)
+	}
+
+	if out == "" {
+		bout = bufio.NewWriter(os.Stdout)
+	} else {
+		fout, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer fout.Close()
+
+		bout = bufio.NewWriter(fout)
+	}
+
+	defer bout.Flush()
+
+	// Iterate over lines
+	for line := 1; scanner.Scan(); line++ {
+		b := scanner.Bed()
+		seq, err := ref.sequence(b.Chr, b.Start-extend, b.End+extend)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+
+		// Print
+		fmt.Fprint(bout, scanner.Text())
+		for _, stat := range stats {
+			fmt.Fprintf(bout, "\t%.4f", stat(seq))
+		}
+		fmt.Fprintln(bout)
+	}
+
+	// If no error, will return nil
+	return scanner.Err()
+}
+
+// ***** ARGUMENTS *************************************************************
+
+var args struct {
+	fastaFile string
+	inFile    string
+	outFile   string
+	extend    int
+	help      bool
+}
+
+func parseArgs() error {
+	if len(os.Args) == 1 {
+		args.help = true
+		return nil
+	}
+
+	// Parse command-line flags.
+	fa := flag.String("f", "", "Reference fasta file. Must be set. Uses the "+
+		"index in <file>.fai if it exists.")
+	in := flag.String("i", "", "Input bed file. Default is standard input.")
+	out := flag.String("o", "", "Output bed file. Default is standard output.")
+	extend := flag.Int("x", 0, "Extend each region by n bases in each "+
+		"direction.")
+
+	flag.Parse()
+
+	// Check arguments.
+	if *fa == "" {
+		return fmt.Errorf("Fasta file not set.")
+	}
+
+	if len(flag.Args()) != 0 {
+		return fmt.Errorf("Unexpected argument: %s", flag.Args()[0])
+	}
+
+	if *extend < 0 {
+		return fmt.Errorf("Bad extension value: %d. Must be at least 0.",
+			*extend)
+	}
+
+	args.fastaFile = *fa
+	args.inFile = *in
+	args.outFile = *out
+	args.extend = *extend
+
+	return nil
+}
+
+var help = `Annotates regions with sequence composition statistics.
+
+Adds columns to each line of the input bed file: GC fraction, CpG
+observed/expected ratio, Shannon entropy (bits) and DUST score. Statistics
+that cannot be computed, like CpG ratio for a region with no Gs, are NaN.
+
+Usage:
+seqstats [options] -f <fasta file>
+
+Accepted options:`
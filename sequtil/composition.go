@@ -0,0 +1,147 @@
+package sequtil
+
+// Sequence composition statistics.
+
+import (
+	"fmt"
+	"iter"
+	"math"
+)
+
+// Returns the counts of A, C, G and T in seq, case insensitive, and U as T.
+func baseCounts(seq []byte) [4]int {
+	var result [4]int
+	for _, b := range seq {
+		if i := ntoi[b]; i != -1 {
+			result[i]++
+		}
+	}
+	return result
+}
+
+// GC returns the fraction of G and C among the A, C, G and T bases of seq,
+// case insensitive. Other characters are ignored. Returns NaN if there are
+// no such bases.
+func GC(seq []byte) float64 {
+	c := baseCounts(seq)
+	total := c[0] + c[1] + c[2] + c[3]
+	if total == 0 {
+		return math.NaN()
+	}
+	return float64(c[1]+c[2]) / float64(total)
+}
+
+// CpGRatio returns the observed/expected ratio of CpG dinucleotides in seq,
+// as defined by Gardiner-Garden and Frommer (1987): the number of CpGs times
+// the number of bases, divided by the number of Cs times the number of Gs.
+// Characters other than ACGT are ignored. Returns NaN if there are no Cs or
+// no Gs.
+func CpGRatio(seq []byte) float64 {
+	c := baseCounts(seq)
+	if c[1] == 0 || c[2] == 0 {
+		return math.NaN()
+	}
+	cpg := 0
+	for i := 1; i < len(seq); i++ {
+		if ntoi[seq[i-1]] == 1 && ntoi[seq[i]] == 2 {
+			cpg++
+		}
+	}
+	total := c[0] + c[1] + c[2] + c[3]
+	return float64(cpg) * float64(total) / (float64(c[1]) * float64(c[2]))
+}
+
+// Dinucleotides returns the frequencies of the 16 dinucleotides in seq,
+// indexed by Ntoi(first)*4+Ntoi(second), so AA is 0 and TT is 15.
+// Dinucleotides with characters other than ACGT are ignored. Frequencies
+// sum to 1, or are all 0 if there are no dinucleotides.
+func Dinucleotides(seq []byte) [16]float64 {
+	var result [16]float64
+	total := 0
+	for i := 1; i < len(seq); i++ {
+		a, b := ntoi[seq[i-1]], ntoi[seq[i]]
+		if a == -1 || b == -1 {
+			continue
+		}
+		result[a*4+b]++
+		total++
+	}
+	if total > 0 {
+		for i := range result {
+			result[i] /= float64(total)
+		}
+	}
+	return result
+}
+
+// Entropy returns the Shannon entropy of the base distribution of seq, in
+// bits: 0 for a single repeated base, up to 2 for equal frequencies of A, C,
+// G and T. Characters other than ACGT are ignored. Returns NaN if there are
+// no such bases.
+func Entropy(seq []byte) float64 {
+	c := baseCounts(seq)
+	total := float64(c[0] + c[1] + c[2] + c[3])
+	if total == 0 {
+		return math.NaN()
+	}
+	result := 0.0
+	for _, n := range c {
+		if n > 0 {
+			p := float64(n) / total
+			result -= p * math.Log2(p)
+		}
+	}
+	return result
+}
+
+// DUST returns the DUST low-complexity score of seq, as in Morgulis et al.
+// (2006): the sum of c*(c-1)/2 over the counts c of each triplet, divided by
+// the number of triplets minus 1. Higher scores mean lower complexity;
+// scores above 2 are usually considered low complexity. Triplets with
+// characters other than ACGT are ignored. Returns 0 if there are fewer than 2
+// triplets.
+func DUST(seq []byte) float64 {
+	var counts [64]int
+	total := 0
+	for i := 2; i < len(seq); i++ {
+		a, b, c := ntoi[seq[i-2]], ntoi[seq[i-1]], ntoi[seq[i]]
+		if a == -1 || b == -1 || c == -1 {
+			continue
+		}
+		counts[a*16+b*4+c]++
+		total++
+	}
+	if total < 2 {
+		return 0
+	}
+	sum := 0
+	for _, c := range counts {
+		sum += c * (c - 1) / 2
+	}
+	return float64(sum) / float64(total-1)
+}
+
+// Windows returns an iterator over the values of f on sliding windows of
+// seq, and the start positions of the windows. Windows are of the given size
+// and start every step bases. The last window is shorter if seq does not
+// divide evenly.
+//
+//	for pos, gc := range Windows(seq, 100, 50, GC) {
+//		...
+//	}
+func Windows(seq []byte, size, step int, f func([]byte) float64) iter.Seq2[
+	int, float64] {
+	if size < 1 || step < 1 {
+		panic(fmt.Sprintf("bad window size or step: %d, %d", size, step))
+	}
+	return func(yield func(int, float64) bool) {
+		for i := 0; i < len(seq); i += step {
+			if !yield(i, f(seq[i:min(i+size, len(seq))])) {
+				return
+			}
+			if i+size >= len(seq) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package sequtil
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Returns whether a and b are equal up to rounding, or are both NaN.
+func floatEqual(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestGC(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"ACGT", 0.5},
+		{"GGCC", 1},
+		{"aattNNNN", 0},
+		{"acgNNg", 0.75},
+		{"NNN", math.NaN()},
+		{"", math.NaN()},
+	}
+	for _, test := range tests {
+		if got := GC([]byte(test.input)); !floatEqual(got, test.want) {
+			t.Errorf("GC(%q)=%v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestCpGRatio(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"CGCG", 2},              // 2 CpGs * 4 / (2*2)
+		{"CCGG", 1},              // 1 * 4 / (2*2)
+		{"GCGC", 1},              // 1 * 4 / (2*2)
+		{"ACGTACGT", 4},          // 2 * 8 / (2*2)
+		{"cgNcg", 2},             // 2 * 4 / (2*2)
+		{"AAAAGGGG", math.NaN()}, // No Cs.
+	}
+	for _, test := range tests {
+		if got := CpGRatio([]byte(test.input)); !floatEqual(got, test.want) {
+			t.Errorf("CpGRatio(%q)=%v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestDinucleotides(t *testing.T) {
+	got := Dinucleotides([]byte("AACNGT"))
+	var want [16]float64
+	want[0], want[1], want[11] = 1.0/3, 1.0/3, 1.0/3 // AA, AC, GT
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dinucleotides(AACNGT)=%v, want %v", got, want)
+	}
+	if got := Dinucleotides([]byte("A")); got != [16]float64{} {
+		t.Errorf("Dinucleotides(A)=%v, want zeros", got)
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"AAAA", 0},
+		{"ACGT", 2},
+		{"AACC", 1},
+		{"AAAAAAAC", 0.5435644431995964},
+		{"", math.NaN()},
+	}
+	for _, test := range tests {
+		if got := Entropy([]byte(test.input)); !floatEqual(got, test.want) {
+			t.Errorf("Entropy(%q)=%v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestDUST(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"AAAAAA", 2},       // 4 AAA: 6 / 3
+		{"ACGACG", 1.0 / 3}, // ACG twice: 1 / 3
+		{"ACGTTG", 0},
+		{"AAAA", 1},   // 2 AAA: 1 / 1
+		{"AAA", 0},    // Single triplet.
+		{"AANAAA", 0}, // Single triplet.
+	}
+	for _, test := range tests {
+		if got := DUST([]byte(test.input)); !floatEqual(got, test.want) {
+			t.Errorf("DUST(%q)=%v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestWindows(t *testing.T) {
+	seq := []byte("GGGGAAAACC")
+	type window struct {
+		pos int
+		gc  float64
+	}
+	tests := []struct {
+		size, step int
+		want       []window
+	}{
+		{4, 4, []window{{0, 1}, {4, 0}, {8, 1}}},
+		{4, 2, []window{{0, 1}, {2, 0.5}, {4, 0}, {6, 0.5}}},
+		{20, 5, []window{{0, 0.6}}},
+	}
+	for _, test := range tests {
+		var got []window
+		for pos, gc := range Windows(seq, test.size, test.step, GC) {
+			got = append(got, window{pos, gc})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Windows(%s,%v,%v)=%v, want %v", seq, test.size,
+				test.step, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package sequtil
+
+// IUPAC ambiguity codes, RNA and validation.
+
+import (
+	"fmt"
+)
+
+// InvalidBaseError reports an unexpected character in a sequence.
+type InvalidBaseError struct {
+	Pos  int  // Position of the character in the sequence
+	Base byte // The character
+}
+
+func (e *InvalidBaseError) Error() string {
+	return fmt.Sprintf("invalid base %q at position %d", e.Base, e.Pos)
+}
+
+// Alphabet is a set of valid sequence characters.
+type Alphabet struct {
+	valid [256]bool
+}
+
+// NewAlphabet returns an alphabet of the given characters.
+func NewAlphabet(chars string) *Alphabet {
+	a := &Alphabet{}
+	for _, c := range []byte(chars) {
+		a.valid[c] = true
+	}
+	return a
+}
+
+// Contains returns whether b is in the alphabet.
+func (a *Alphabet) Contains(b byte) bool {
+	return a.valid[b]
+}
+
+// Common alphabets, in upper and lower case.
+var (
+	DNA   = NewAlphabet("ACGTacgt")                         // Unambiguous DNA.
+	DNAN  = NewAlphabet("ACGTNacgtn")                       // DNA with N.
+	RNA   = NewAlphabet("ACGUacgu")                         // Unambiguous RNA.
+	IUPAC = NewAlphabet("ACGTURYSWKMBDHVNacgturyswkmbdhvn") // Ambiguity codes.
+)
+
+// FirstInvalid returns the position of the first character in seq that is
+// not in the alphabet, or -1 if all are valid.
+func FirstInvalid(seq []byte, a *Alphabet) int {
+	for i, b := range seq {
+		if !a.valid[b] {
+			return i
+		}
+	}
+	return -1
+}
+
+// Validate returns an InvalidBaseError for the first character in seq that
+// is not in the alphabet, or nil if all are valid.
+func Validate(seq []byte, a *Alphabet) error {
+	if i := FirstInvalid(seq, a); i != -1 {
+		return &InvalidBaseError{i, seq[i]}
+	}
+	return nil
+}
+
+// Maps an IUPAC code to its complement, or 0 if not a code.
+var complementIUPAC [256]byte
+
+func init() {
+	pairs := []string{"AT", "CG", "RY", "SS", "WW", "KM", "BV", "DH", "NN"}
+	for _, p := range pairs {
+		for _, c := range []string{p, p[1:] + p[:1]} {
+			complementIUPAC[c[0]] = c[1]
+			complementIUPAC[c[0]+'a'-'A'] = c[1] + 'a' - 'A'
+		}
+	}
+	complementIUPAC['U'], complementIUPAC['u'] = 'A', 'a'
+}
+
+// ComplementIUPAC returns the complement of an IUPAC nucleotide code, and
+// whether it is a valid code. U is complemented to A. Case is preserved.
+func ComplementIUPAC(b byte) (byte, bool) {
+	c := complementIUPAC[b]
+	return c, c != 0
+}
+
+// ReverseComplementIUPAC writes to dst the reverse complement of src, which
+// may contain IUPAC ambiguity codes in upper or lower case. U is
+// complemented to A. Returns an InvalidBaseError for other characters, in
+// which case dst is partially written.
+func ReverseComplementIUPAC(dst, src []byte) error {
+	if len(dst) < len(src) {
+		return fmt.Errorf("dst is too short: %v, want at least %v",
+			len(dst), len(src))
+	}
+	for i, b := range src {
+		c := complementIUPAC[b]
+		if c == 0 {
+			return &InvalidBaseError{i, b}
+		}
+		dst[len(src)-1-i] = c
+	}
+	return nil
+}
+
+// ReverseComplementRNA is like ReverseComplementIUPAC, but complements A to
+// U, for RNA sequences.
+func ReverseComplementRNA(dst, src []byte) error {
+	if err := ReverseComplementIUPAC(dst, src); err != nil {
+		return err
+	}
+	ToRNA(dst[:len(src)], dst[:len(src)])
+	return nil
+}
+
+// ToRNA writes to dst the sequence in src, with T replaced by U. Case is
+// preserved. dst and src may be the same slice.
+func ToRNA(dst, src []byte) {
+	replaceBase(dst, src, 'T', 'U')
+}
+
+// ToDNA writes to dst the sequence in src, with U replaced by T. Case is
+// preserved. dst and src may be the same slice.
+func ToDNA(dst, src []byte) {
+	replaceBase(dst, src, 'U', 'T')
+}
+
+// Writes src to dst, replacing the uppercase base from with to, and
+// likewise in lowercase.
+func replaceBase(dst, src []byte, from, to byte) {
+	if len(dst) < len(src) {
+		panic(fmt.Sprintf("dst is too short: %v, want at least %v",
+			len(dst), len(src)))
+	}
+	for i, b := range src {
+		switch b {
+		case from:
+			dst[i] = to
+		case from + 'a' - 'A':
+			dst[i] = to + 'a' - 'A'
+		default:
+			dst[i] = b
+		}
+	}
+}
+
+// DNATo2BitErr is like DNATo2Bit, but returns an InvalidBaseError instead of
+// panicking. U is encoded like T.
+func DNATo2BitErr(dst, src []byte) error {
+	if len(dst) < (len(src)+3)/4 {
+		return fmt.Errorf("dst is too short: %v, want at least %v",
+			len(dst), (len(src)+3)/4)
+	}
+	if i := FirstInvalid(src, dnaOrU); i != -1 {
+		return &InvalidBaseError{i, src[i]}
+	}
+	DNATo2Bit(dst, src)
+	return nil
+}
+
+// DNA and RNA bases.
+var dnaOrU = NewAlphabet("ACGTUacgtu")
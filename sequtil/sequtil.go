@@ -18,9 +18,10 @@ func init() {
 	ntoi['c'], ntoi['C'] = 1, 1
 	ntoi['g'], ntoi['G'] = 2, 2
 	ntoi['t'], ntoi['T'] = 3, 3
+	ntoi['u'], ntoi['U'] = 3, 3
 }
 
-// Ntoi converts a nucleotide to an int.
+// Ntoi converts a nucleotide to an int. U is converted like T.
 // Returns -1 for unknown nucleotides.
 func Ntoi(nuc byte) int {
 	return ntoi[nuc]
@@ -44,6 +45,7 @@ func Iton(num int) byte {
 }
 
 // NgramCounts returns the n-gram count vector for the given sequence.
+// For larger n, use the kmer package.
 func NgramCounts(n int, sequence []byte) []int {
 	// Check input.
 	if n < 1 || n > 10 {
@@ -87,7 +89,8 @@ func NgramCounts(n int, sequence []byte) []int {
 }
 
 // ReverseComplement writes to dst the reverse complement of src.
-// Characters not in "aAcCgGtTnN" will cause a panic.
+// Characters not in "aAcCgGtTnN" will cause a panic. For ambiguity codes and
+// an error instead of a panic, use ReverseComplementIUPAC.
 func ReverseComplement(dst, src []byte) {
 	if len(dst) < len(src) {
 		panic(fmt.Sprintf("dst is too short: %v, want at least %v",
@@ -132,7 +135,8 @@ func ReverseComplementString(s string) string {
 }
 
 // DNATo2Bit writes to dst the 2-bit representation of the DNA sequence in src.
-// Any character not in "aAcCgGtT" will cause a panic.
+// U is encoded like T. Any character not in "aAcCgGtTuU" will cause a panic.
+// For an error instead of a panic, use DNATo2BitErr.
 func DNATo2Bit(dst, src []byte) {
 	if len(dst) < (len(src)+3)/4 {
 		panic(fmt.Sprintf("dst is too short: %v, want at least %v",
@@ -147,7 +151,7 @@ func DNATo2Bit(dst, src []byte) {
 		}
 		dbInt := Ntoi(b)
 		if dbInt == -1 {
-			panic(fmt.Sprintf("Unexpected base value: %v, want aAcCgGtT", b))
+			panic(fmt.Sprintf("Unexpected base value: %v, want aAcCgGtTuU", b))
 		}
 		db := byte(dbInt) << shift
 		dst[di] |= db
@@ -80,3 +80,119 @@ func BenchmarkDNAFrom2Bit(b *testing.B) {
 		DNAFrom2Bit(dna, twobit)
 	}
 }
+
+func TestReverseComplementIUPAC(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"ACGTN", "NACGT"},
+		{"acgtn", "nacgt"},
+		{"RYSWKMBDHV", "BDHVKMWSRY"},
+		{"ryswkmbdhv", "bdhvkmwsry"},
+		{"AUGu", "aCAT"},
+	}
+	for _, test := range tests {
+		got := make([]byte, len(test.input))
+		if err := ReverseComplementIUPAC(got, []byte(test.input)); err != nil {
+			t.Fatalf("ReverseComplementIUPAC(%q) failed: %v", test.input, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("ReverseComplementIUPAC(%q)=%q, want %q", test.input,
+				got, test.want)
+		}
+	}
+
+	err := ReverseComplementIUPAC(make([]byte, 5), []byte("ACX-G"))
+	want := &InvalidBaseError{2, 'X'}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("ReverseComplementIUPAC(ACX-G) error=%v, want %v", err, want)
+	}
+	if err := ReverseComplementIUPAC(make([]byte, 1),
+		[]byte("AC")); err == nil {
+		t.Errorf("ReverseComplementIUPAC() with short dst succeeded, " +
+			"want error")
+	}
+}
+
+func TestReverseComplementRNA(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"AUGC", "GCAU"},
+		{"augcn", "ngcau"},
+		{"ACGT", "ACGU"},
+	}
+	for _, test := range tests {
+		got := make([]byte, len(test.input))
+		if err := ReverseComplementRNA(got, []byte(test.input)); err != nil {
+			t.Fatalf("ReverseComplementRNA(%q) failed: %v", test.input, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("ReverseComplementRNA(%q)=%q, want %q", test.input,
+				got, test.want)
+		}
+	}
+}
+
+func TestToRNA(t *testing.T) {
+	seq := []byte("ACGTacgtN")
+	ToRNA(seq, seq)
+	if want := "ACGUacguN"; string(seq) != want {
+		t.Errorf("ToRNA(ACGTacgtN)=%q, want %q", seq, want)
+	}
+	ToDNA(seq, seq)
+	if want := "ACGTacgtN"; string(seq) != want {
+		t.Errorf("ToDNA(ACGUacguN)=%q, want %q", seq, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		input    string
+		alphabet *Alphabet
+		want     int
+	}{
+		{"ACGTacgt", DNA, -1},
+		{"ACGTN", DNA, 4},
+		{"ACGTN", DNAN, -1},
+		{"ACGU", DNAN, 3},
+		{"ACGU", RNA, -1},
+		{"ACGT", RNA, 3},
+		{"ACGTURYN", IUPAC, -1},
+		{"ACGT-", IUPAC, 4},
+		{"", DNA, -1},
+	}
+	for _, test := range tests {
+		got := FirstInvalid([]byte(test.input), test.alphabet)
+		if got != test.want {
+			t.Errorf("FirstInvalid(%q)=%v, want %v", test.input, got,
+				test.want)
+		}
+		err := Validate([]byte(test.input), test.alphabet)
+		if (err == nil) != (test.want == -1) {
+			t.Errorf("Validate(%q)=%v, want error at %v", test.input, err,
+				test.want)
+		}
+	}
+}
+
+func TestDNATo2BitErr(t *testing.T) {
+	got := make([]byte, 2)
+	if err := DNATo2BitErr(got, []byte("acguTGCA")); err != nil {
+		t.Fatalf("DNATo2BitErr(acguTGCA) failed: %v", err)
+	}
+	if want := []byte{0b00011011, 0b11100100}; !reflect.DeepEqual(got,
+		want) {
+		t.Errorf("DNATo2BitErr(acguTGCA)=%v, want %v", got, want)
+	}
+	err := DNATo2BitErr(got, []byte("acgN"))
+	if want := (&InvalidBaseError{3, 'N'}); !reflect.DeepEqual(err, want) {
+		t.Errorf("DNATo2BitErr(acgN) error=%v, want %v", err, want)
+	}
+	if err := DNATo2BitErr(got, []byte("acgtacgtA")); err == nil {
+		t.Errorf("DNATo2BitErr() with short dst succeeded, want error")
+	}
+}
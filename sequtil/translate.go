@@ -0,0 +1,309 @@
+package sequtil
+
+// Translation of DNA to protein.
+
+import (
+	"fmt"
+)
+
+// GeneticCode is a table that maps codons to amino acids, as published by
+// NCBI: https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi
+//
+// All current NCBI tables are supported: 1-6, 9-16 (except 15), 21-33. In
+// tables 27, 28 and 31, some codons are stops only at the end of a gene;
+// they are translated as their amino acids.
+type GeneticCode struct {
+	ID     int    // NCBI table number
+	Name   string // NCBI table name
+	aas    string // Amino acid per codon, in NCBI order (TCAG)
+	starts string // 'M' for start codons, in NCBI order
+}
+
+// Genetic codes, by NCBI table number.
+var geneticCodes = []*GeneticCode{
+	{1, "Standard",
+		"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M---------------M---------------M----------------------------"},
+	{2, "Vertebrate Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+		"--------------------------------MMMM---------------M------------"},
+	{3, "Yeast Mitochondrial",
+		"FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------------------------------MM---------------M------------"},
+	{4, "Mold, Protozoan, and Coelenterate Mitochondrial and " +
+		"Mycoplasma/Spiroplasma",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--MM---------------M------------MMMM---------------M------------"},
+	{5, "Invertebrate Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG",
+		"---M----------------------------MMMM---------------M------------"},
+	{6, "Ciliate, Dasycladacean and Hexamita Nuclear",
+		"FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{9, "Echinoderm and Flatworm Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"-----------------------------------M---------------M------------"},
+	{10, "Euplotid Nuclear",
+		"FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{11, "Bacterial, Archaeal and Plant Plastid",
+		"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M---------------M------------MMMM---------------M------------"},
+	{12, "Alternative Yeast Nuclear",
+		"FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-------------------M---------------M----------------------------"},
+	{13, "Ascidian Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG",
+		"---M------------------------------MM---------------M------------"},
+	{14, "Alternative Flatworm Mitochondrial",
+		"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{16, "Chlorophycean Mitochondrial",
+		"FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{21, "Trematode Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"-----------------------------------M---------------M------------"},
+	{22, "Scenedesmus obliquus Mitochondrial",
+		"FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{23, "Thraustochytrium Mitochondrial",
+		"FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------------------------M--M---------------M------------"},
+	{24, "Rhabdopleuridae Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		"---M---------------M---------------M---------------M------------"},
+	{25, "Candidate Division SR1 and Gracilibacteria",
+		"FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M-------------------------------M---------------M------------"},
+	{26, "Pachysolen tannophilus Nuclear",
+		"FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-------------------M---------------M----------------------------"},
+	{27, "Karyorelict Nuclear",
+		"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{28, "Condylostoma Nuclear",
+		"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{29, "Mesodinium Nuclear",
+		"FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{30, "Peritrich Nuclear",
+		"FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{31, "Blastocrithidia Nuclear",
+		"FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"-----------------------------------M----------------------------"},
+	{32, "Balanophoraceae Plastid",
+		"FFLLSSSSYY*WCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M---------------M------------MMMM---------------M------------"},
+	{33, "Cephalodiscidae Mitochondrial",
+		"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		"---M---------------M---------------M---------------M------------"},
+}
+
+// StandardCode is the standard genetic code (table 1).
+var StandardCode = geneticCodes[0]
+
+// GetGeneticCode returns the genetic code with the given NCBI table number.
+func GetGeneticCode(id int) (*GeneticCode, error) {
+	for _, c := range geneticCodes {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported genetic code: %d", id)
+}
+
+// Maps a nucleotide code to the set of bases it represents, as bits in NCBI
+// order: T=1, C=2, A=4, G=8.
+var baseSets [256]uint8
+
+func init() {
+	sets := map[byte]uint8{'T': 1, 'U': 1, 'C': 2, 'A': 4, 'G': 8,
+		'R': 4 | 8, 'Y': 1 | 2, 'S': 2 | 8, 'W': 1 | 4, 'K': 1 | 8,
+		'M': 2 | 4, 'B': 1 | 2 | 8, 'D': 1 | 4 | 8, 'H': 1 | 2 | 4,
+		'V': 2 | 4 | 8, 'N': 15}
+	for b, s := range sets {
+		baseSets[b] = s
+		baseSets[b+'a'-'A'] = s
+	}
+}
+
+// Returns the NCBI indexes of the codons represented by the given codon,
+// which may contain ambiguity codes.
+func codonIndexes(codon []byte) []int {
+	if len(codon) != 3 {
+		return nil
+	}
+	result := []int{0}
+	for _, b := range codon {
+		set := baseSets[b]
+		var next []int
+		for _, i := range result {
+			for j := 0; j < 4; j++ {
+				if set&(1<<j) != 0 {
+					next = append(next, i*4+j)
+				}
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// Translate returns the amino acid of a codon, or '*' for a stop codon.
+// Codons with ambiguity codes are translated if all the codons they
+// represent agree. Otherwise returns 'X'.
+func (c *GeneticCode) Translate(codon []byte) byte {
+	var result byte = 'X'
+	for i, idx := range codonIndexes(codon) {
+		if i > 0 && c.aas[idx] != result {
+			return 'X'
+		}
+		result = c.aas[idx]
+	}
+	return result
+}
+
+// IsStart returns whether a codon is a start codon, including alternative
+// start codons.
+func (c *GeneticCode) IsStart(codon []byte) bool {
+	idx := codonIndexes(codon)
+	return len(idx) == 1 && c.starts[idx[0]] == 'M'
+}
+
+// IsStop returns whether a codon is a stop codon.
+func (c *GeneticCode) IsStop(codon []byte) bool {
+	return c.Translate(codon) == '*'
+}
+
+// Codons returns the codons of an amino acid, or of stop codons for '*'.
+func (c *GeneticCode) Codons(aa byte) []string {
+	var result []string
+	for i := 0; i < 64; i++ {
+		if c.aas[i] == aa {
+			result = append(result, string([]byte{"TCAG"[i/16],
+				"TCAG"[i/4%4], "TCAG"[i%4]}))
+		}
+	}
+	return result
+}
+
+// Translate returns the amino acids of the complete codons of seq. Stop codons
+// are translated to '*'.
+func Translate(seq []byte, code *GeneticCode) []byte {
+	result := make([]byte, len(seq)/3)
+	for i := range result {
+		result[i] = code.Translate(seq[i*3 : i*3+3])
+	}
+	return result
+}
+
+// SixFrames returns the translations of the 3 forward frames of seq,
+// followed by the 3 frames of its reverse complement. Ambiguity codes are
+// allowed.
+func SixFrames(seq []byte, code *GeneticCode) ([6][]byte, error) {
+	var result [6][]byte
+	rc := make([]byte, len(seq))
+	if err := ReverseComplementIUPAC(rc, seq); err != nil {
+		return result, err
+	}
+	for i := 0; i < 3 && i < len(seq); i++ {
+		result[i] = Translate(seq[i:], code)
+		result[i+3] = Translate(rc[i:], code)
+	}
+	return result, nil
+}
+
+// ORF is an open reading frame: a start codon followed by codons up to a
+// stop codon.
+type ORF struct {
+	Start   int    // 0-based start on the forward strand, inclusive
+	End     int    // 0-based end on the forward strand, after the stop codon
+	Strand  byte   // '+' or '-'
+	Protein []byte // Translation, without the stop codon
+}
+
+// FindORFs returns the ORFs of seq on both strands whose protein is at least
+// minLength amino acids long. ORFs start at ATG, or at any start codon of the
+// genetic code if altStarts is true, and end at the first stop codon in
+// frame. ORFs that do not reach a stop codon are not reported. The protein
+// begins with M regardless of the start codon.
+func FindORFs(seq []byte, code *GeneticCode, minLength int,
+	altStarts bool) ([]ORF, error) {
+	rc := make([]byte, len(seq))
+	if err := ReverseComplementIUPAC(rc, seq); err != nil {
+		return nil, err
+	}
+	result := findORFs(seq, code, minLength, altStarts)
+	for _, orf := range findORFs(rc, code, minLength, altStarts) {
+		orf.Start, orf.End = len(seq)-orf.End, len(seq)-orf.Start
+		orf.Strand = '-'
+		result = append(result, orf)
+	}
+	return result, nil
+}
+
+// Returns the ORFs of the forward strand of seq.
+func findORFs(seq []byte, code *GeneticCode, minLength int,
+	altStarts bool) []ORF {
+	var result []ORF
+	for frame := 0; frame < 3; frame++ {
+		start := -1
+		for i := frame; i+3 <= len(seq); i += 3 {
+			codon := seq[i : i+3]
+			if start == -1 {
+				if isStart(codon, code, altStarts) {
+					start = i
+				}
+				continue
+			}
+			if !code.IsStop(codon) {
+				continue
+			}
+			protein := Translate(seq[start:i], code)
+			protein[0] = 'M'
+			if len(protein) >= minLength {
+				result = append(result, ORF{start, i + 3, '+', protein})
+			}
+			start = -1
+		}
+	}
+	return result
+}
+
+// Returns whether codon starts an ORF.
+func isStart(codon []byte, code *GeneticCode, altStarts bool) bool {
+	if altStarts {
+		return code.IsStart(codon)
+	}
+	idx := codonIndexes(codon)
+	return len(idx) == 1 && idx[0] == 0b10_00_11 // ATG
+}
+
+// Maps a set of bases (see baseSets) to its ambiguity code.
+var setCodes = [16]byte{0, 'T', 'C', 'Y', 'A', 'W', 'M', 'H', 'G', 'K', 'S',
+	'B', 'R', 'D', 'V', 'N'}
+
+// ReverseTranslate returns a DNA sequence with ambiguity codes that covers
+// all the codons of each amino acid in protein. Since positions are
+// represented independently, the result may also match other codons; for
+// example, L (TTA, TTG, CTN) is represented as YTN.
+func ReverseTranslate(protein []byte, code *GeneticCode) ([]byte, error) {
+	result := make([]byte, 0, len(protein)*3)
+	for i, aa := range protein {
+		codons := code.Codons(aa)
+		if len(codons) == 0 {
+			return nil, fmt.Errorf("no codons for %q at position %d", aa, i)
+		}
+		for j := 0; j < 3; j++ {
+			var set uint8
+			for _, codon := range codons {
+				set |= baseSets[codon[j]]
+			}
+			result = append(result, setCodes[set])
+		}
+	}
+	return result, nil
+}
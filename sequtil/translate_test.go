@@ -0,0 +1,209 @@
+package sequtil
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	vmt, err := GetGeneticCode(2)
+	if err != nil {
+		t.Fatalf("GetGeneticCode(2) failed: %v", err)
+	}
+	tests := []struct {
+		input string
+		code  *GeneticCode
+		want  string
+	}{
+		{"ATGGCCATTGTAATGGGCCGCTGAAAGGGTGCCCGATAG", StandardCode,
+			"MAIVMGR*KGAR*"},
+		{"ATGGCCATTGTAATGGGCCGCTGAAAGGGTGCCCGATAG", vmt, "MAIVMGRWKGAR*"},
+		{"atggccattgtaatgggccgctgaaagggtgcccgatagAT", StandardCode,
+			"MAIVMGR*KGAR*"},
+		{"AUGUUUUAA", StandardCode, "MF*"},
+		{"GCNYTNAGRTAR", StandardCode, "AXR*"},
+		{"ATGNNNA-G", StandardCode, "MXX"},
+		{"", StandardCode, ""},
+	}
+	for _, test := range tests {
+		got := Translate([]byte(test.input), test.code)
+		if string(got) != test.want {
+			t.Errorf("Translate(%q,%d)=%q, want %q", test.input, test.code.ID,
+				got, test.want)
+		}
+	}
+}
+
+func TestGeneticCode_starts(t *testing.T) {
+	tests := []struct {
+		id   int
+		want []string
+	}{
+		{1, []string{"TTG", "CTG", "ATG"}},
+		{2, []string{"ATT", "ATC", "ATA", "ATG", "GTG"}},
+		{11, []string{"TTG", "CTG", "ATT", "ATC", "ATA", "ATG", "GTG"}},
+	}
+	for _, test := range tests {
+		code, err := GetGeneticCode(test.id)
+		if err != nil {
+			t.Fatalf("GetGeneticCode(%v) failed: %v", test.id, err)
+		}
+		var got []string
+		for _, a := range "TCAG" {
+			for _, b := range "TCAG" {
+				for _, c := range "TCAG" {
+					codon := string([]rune{a, b, c})
+					if code.IsStart([]byte(codon)) {
+						got = append(got, codon)
+					}
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("starts of table %v=%v, want %v", test.id, got, test.want)
+		}
+	}
+	if _, err := GetGeneticCode(7); err == nil {
+		t.Errorf("GetGeneticCode(7) succeeded, want error")
+	}
+}
+
+func TestGetGeneticCode(t *testing.T) {
+	// A codon that is reassigned in each table, compared to the standard.
+	tests := []struct {
+		id    int
+		codon string
+		want  byte
+	}{
+		{1, "TGA", '*'}, {2, "AGA", '*'}, {3, "CTT", 'T'}, {4, "TGA", 'W'},
+		{5, "AGG", 'S'}, {6, "TAA", 'Q'}, {9, "AAA", 'N'}, {10, "TGA", 'C'},
+		{11, "TGA", '*'}, {12, "CTG", 'S'}, {13, "AGA", 'G'},
+		{14, "TAA", 'Y'}, {16, "TAG", 'L'}, {21, "ATA", 'M'},
+		{22, "TCA", '*'}, {23, "TTA", '*'}, {24, "AGG", 'K'},
+		{25, "TGA", 'G'}, {26, "CTG", 'A'}, {27, "TGA", 'W'},
+		{28, "TAG", 'Q'}, {29, "TAG", 'Y'}, {30, "TAA", 'E'},
+		{31, "TAG", 'E'}, {32, "TAG", 'W'}, {33, "TAA", 'Y'},
+	}
+	for _, test := range tests {
+		code, err := GetGeneticCode(test.id)
+		if err != nil {
+			t.Fatalf("GetGeneticCode(%v) failed: %v", test.id, err)
+		}
+		if code.ID != test.id || len(code.aas) != 64 ||
+			len(code.starts) != 64 {
+			t.Fatalf("GetGeneticCode(%v)=%v, want a table of 64 codons",
+				test.id, code)
+		}
+		if got := code.Translate([]byte(test.codon)); got != test.want {
+			t.Errorf("table %v: Translate(%v)=%c, want %c", test.id,
+				test.codon, got, test.want)
+		}
+	}
+	if len(geneticCodes) != len(tests) {
+		t.Errorf("len(geneticCodes)=%v, want %v", len(geneticCodes),
+			len(tests))
+	}
+	for _, id := range []int{0, 7, 8, 15, 17, 20, 34, -1} {
+		if _, err := GetGeneticCode(id); err == nil {
+			t.Errorf("GetGeneticCode(%v) succeeded, want error", id)
+		}
+	}
+}
+
+func TestGeneticCode_codons(t *testing.T) {
+	tests := []struct {
+		aa   byte
+		want []string
+	}{
+		{'M', []string{"ATG"}},
+		{'*', []string{"TAA", "TAG", "TGA"}},
+		{'L', []string{"TTA", "TTG", "CTT", "CTC", "CTA", "CTG"}},
+		{'B', nil},
+	}
+	for _, test := range tests {
+		got := StandardCode.Codons(test.aa)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Codons(%c)=%v, want %v", test.aa, got, test.want)
+		}
+		for _, codon := range got {
+			if aa := StandardCode.Translate([]byte(codon)); aa != test.aa {
+				t.Errorf("Translate(%s)=%c, want %c", codon, aa, test.aa)
+			}
+		}
+	}
+}
+
+func TestSixFrames(t *testing.T) {
+	got, err := SixFrames([]byte("ATGAAATAGC"), StandardCode)
+	if err != nil {
+		t.Fatalf("SixFrames() failed: %v", err)
+	}
+	// Reverse complement: GCTATTTCAT
+	want := []string{"MK*", "*NS", "EI", "AIS", "LFH", "YF"}
+	for i := range want {
+		if string(got[i]) != want[i] {
+			t.Errorf("SixFrames()[%v]=%q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindORFs(t *testing.T) {
+	// ORF on the forward strand at 2-17, and ORF on the reverse strand at
+	// 17-29 (reverse complement of ATGCCCTTTTGA).
+	seq := "CCATGAAACCCGGGTAGTCAAAAGGGCATCC"
+	got, err := FindORFs([]byte(seq), StandardCode, 3, false)
+	if err != nil {
+		t.Fatalf("FindORFs(%q) failed: %v", seq, err)
+	}
+	want := []ORF{
+		{2, 17, '+', []byte("MKPG")},
+		{17, 29, '-', []byte("MPF")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindORFs(%q)=%v, want %v", seq, got, want)
+	}
+
+	got, _ = FindORFs([]byte(seq), StandardCode, 4, false)
+	if len(got) != 1 || got[0].Start != 2 {
+		t.Errorf("FindORFs(%q,min=4)=%v, want only the forward ORF", seq, got)
+	}
+}
+
+func TestFindORFs_altStarts(t *testing.T) {
+	seq := "GTGAAACCCTAA"
+	got, _ := FindORFs([]byte(seq), StandardCode, 1, false)
+	if len(got) != 0 {
+		t.Errorf("FindORFs(%q)=%v, want none", seq, got)
+	}
+	bacterial, _ := GetGeneticCode(11)
+	got, _ = FindORFs([]byte(seq), bacterial, 1, true)
+	want := []ORF{{0, 12, '+', []byte("MKP")}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindORFs(%q,alt)=%v, want %v", seq, got, want)
+	}
+}
+
+func TestReverseTranslate(t *testing.T) {
+	got, err := ReverseTranslate([]byte("MLW*"), StandardCode)
+	if err != nil {
+		t.Fatalf("ReverseTranslate(MLW*) failed: %v", err)
+	}
+	if want := "ATGYTNTGGTRR"; string(got) != want {
+		t.Errorf("ReverseTranslate(MLW*)=%q, want %q", got, want)
+	}
+	for i, aa := range []byte("MLW*") {
+		for _, codon := range StandardCode.Codons(aa) {
+			if !slices.ContainsFunc(codonIndexes(got[i*3:i*3+3]),
+				func(idx int) bool {
+					return idx == codonIndexes([]byte(codon))[0]
+				}) {
+				t.Errorf("ReverseTranslate(%c)=%s, does not cover %s", aa,
+					got[i*3:i*3+3], codon)
+			}
+		}
+	}
+	if _, err := ReverseTranslate([]byte("MZ"), StandardCode); err == nil {
+		t.Errorf("ReverseTranslate(MZ) succeeded, want error")
+	}
+}
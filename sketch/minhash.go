@@ -0,0 +1,212 @@
+package sketch
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+
+	"github.com/fluhus/golgi/kmer"
+)
+
+// ----- MINHASH ---------------------------------------------------------------
+
+// MinHash is a bottom-n sketch: the n lowest k-mer hashes of a set.
+type MinHash struct {
+	n      int
+	hashes []uint64 // Sorted.
+}
+
+// NewMinHash returns an empty sketch of size n.
+func NewMinHash(n int) *MinHash {
+	if n < 1 {
+		panic(fmt.Sprintf("bad n: %d", n))
+	}
+	return &MinHash{n: n}
+}
+
+// AddHash adds a hash to the sketch.
+func (m *MinHash) AddHash(h uint64) {
+	if len(m.hashes) == m.n && h >= m.hashes[len(m.hashes)-1] {
+		return
+	}
+	i, found := slices.BinarySearch(m.hashes, h)
+	if found {
+		return
+	}
+	if len(m.hashes) == m.n {
+		m.hashes = m.hashes[:len(m.hashes)-1]
+	}
+	m.hashes = slices.Insert(m.hashes, i, h)
+}
+
+// Add adds the canonical k-mers of a sequence to the sketch.
+func (m *MinHash) Add(seq []byte, k int) {
+	for _, x := range kmer.AllCanonical(seq, k) {
+		m.AddHash(Hash(x))
+	}
+}
+
+// Hashes returns the hashes in the sketch, in ascending order.
+func (m *MinHash) Hashes() []uint64 {
+	return slices.Clone(m.hashes)
+}
+
+// Jaccard estimates the Jaccard similarity of the sets of m and other: the
+// fraction of the lowest hashes of their union that are in both. Sketches
+// should have the same size.
+func (m *MinHash) Jaccard(other *MinHash) float64 {
+	n := min(m.n, other.n)
+	union, shared := 0, 0
+	for a, b := range mergeHashes(m.hashes, other.hashes) {
+		if union == n {
+			break
+		}
+		union++
+		if a && b {
+			shared++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// Containment estimates the fraction of the set of m that is contained in
+// the set of other. Only hashes in the range covered by both sketches are
+// compared, so the estimate is less accurate when the set of other is much
+// larger.
+func (m *MinHash) Containment(other *MinHash) float64 {
+	limit := uint64(math.MaxUint64)
+	if len(other.hashes) == other.n {
+		limit = other.hashes[len(other.hashes)-1]
+	}
+	total, shared := 0, 0
+	for _, h := range m.hashes {
+		if h > limit {
+			break
+		}
+		total++
+		if _, ok := slices.BinarySearch(other.hashes, h); ok {
+			shared++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(shared) / float64(total)
+}
+
+// ----- FRACMINHASH -----------------------------------------------------------
+
+// FracMinHash is a scaled sketch: all k-mer hashes below a fixed fraction of
+// the hash range. Unlike MinHash, its size grows with the set, and it
+// estimates containment between sets of different sizes.
+type FracMinHash struct {
+	maxHash uint64
+	hashes  map[uint64]struct{}
+}
+
+// NewFracMinHash returns an empty sketch that keeps about 1/scale of the
+// hashes.
+func NewFracMinHash(scale int) *FracMinHash {
+	if scale < 1 {
+		panic(fmt.Sprintf("bad scale: %d", scale))
+	}
+	return &FracMinHash{math.MaxUint64 / uint64(scale),
+		map[uint64]struct{}{}}
+}
+
+// AddHash adds a hash to the sketch, if it is below the threshold.
+func (f *FracMinHash) AddHash(h uint64) {
+	if h <= f.maxHash {
+		f.hashes[h] = struct{}{}
+	}
+}
+
+// Add adds the canonical k-mers of a sequence to the sketch.
+func (f *FracMinHash) Add(seq []byte, k int) {
+	for _, x := range kmer.AllCanonical(seq, k) {
+		f.AddHash(Hash(x))
+	}
+}
+
+// Len returns the number of hashes in the sketch.
+func (f *FracMinHash) Len() int {
+	return len(f.hashes)
+}
+
+// Hashes returns the hashes in the sketch, in ascending order.
+func (f *FracMinHash) Hashes() []uint64 {
+	result := make([]uint64, 0, len(f.hashes))
+	for h := range f.hashes {
+		result = append(result, h)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// Returns the number of hashes in both sketches. Panics if the sketches have
+// different scales.
+func (f *FracMinHash) intersection(other *FracMinHash) int {
+	if f.maxHash != other.maxHash {
+		panic("comparing sketches of different scales")
+	}
+	a, b := f.hashes, other.hashes
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	result := 0
+	for h := range a {
+		if _, ok := b[h]; ok {
+			result++
+		}
+	}
+	return result
+}
+
+// Jaccard estimates the Jaccard similarity of the sets of f and other.
+func (f *FracMinHash) Jaccard(other *FracMinHash) float64 {
+	shared := f.intersection(other)
+	union := len(f.hashes) + len(other.hashes) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// Containment estimates the fraction of the set of f that is contained in
+// the set of other.
+func (f *FracMinHash) Containment(other *FracMinHash) float64 {
+	if len(f.hashes) == 0 {
+		return 0
+	}
+	return float64(f.intersection(other)) / float64(len(f.hashes))
+}
+
+// Returns an iterator over the union of two sorted hash lists, in ascending
+// order, with whether each hash is in a and in b.
+func mergeHashes(a, b []uint64) iter.Seq2[bool, bool] {
+	return func(yield func(bool, bool) bool) {
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			var inA, inB bool
+			switch {
+			case j == len(b) || (i < len(a) && a[i] < b[j]):
+				inA = true
+				i++
+			case i == len(a) || b[j] < a[i]:
+				inB = true
+				j++
+			default:
+				inA, inB = true, true
+				i++
+				j++
+			}
+			if !yield(inA, inB) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package sketch
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestMinHash(t *testing.T) {
+	m := NewMinHash(3)
+	for _, h := range []uint64{5, 3, 9, 3, 1, 7} {
+		m.AddHash(h)
+	}
+	if got, want := m.Hashes(), []uint64{1, 3, 5}; !slices.Equal(got, want) {
+		t.Errorf("Hashes()=%v, want %v", got, want)
+	}
+}
+
+// Returns two sequences with the given fraction of shared prefix.
+func sharedSeqs(rnd *rand.Rand, n int, shared float64) ([]byte, []byte) {
+	common := randomSeq(rnd, int(float64(n)*shared), "ACGT")
+	a := append(slices.Clone(common), randomSeq(rnd, n-len(common),
+		"ACGT")...)
+	b := append(slices.Clone(common), randomSeq(rnd, n-len(common),
+		"ACGT")...)
+	return a, b
+}
+
+func TestMinHash_jaccard(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	a, b := sharedSeqs(rnd, 100000, 0.5)
+	ma, mb := NewMinHash(1000), NewMinHash(1000)
+	ma.Add(a, 21)
+	mb.Add(b, 21)
+	// Half shared -> Jaccard is 1/3.
+	if got := ma.Jaccard(mb); math.Abs(got-1.0/3) > 0.05 {
+		t.Errorf("Jaccard()=%v, want about 0.33", got)
+	}
+	if got := ma.Containment(mb); math.Abs(got-0.5) > 0.05 {
+		t.Errorf("Containment()=%v, want about 0.5", got)
+	}
+	if got := ma.Jaccard(ma); got != 1 {
+		t.Errorf("Jaccard(self)=%v, want 1", got)
+	}
+	if got := NewMinHash(10).Jaccard(NewMinHash(10)); got != 0 {
+		t.Errorf("Jaccard(empty)=%v, want 0", got)
+	}
+}
+
+func TestFracMinHash(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	a, b := sharedSeqs(rnd, 200000, 0.5)
+	fa, fb := NewFracMinHash(100), NewFracMinHash(100)
+	fa.Add(a, 21)
+	fb.Add(b, 21)
+	if n := fa.Len(); n < 1500 || n > 2500 {
+		t.Errorf("Len()=%v, want about 2000", n)
+	}
+	if got := fa.Jaccard(fb); math.Abs(got-1.0/3) > 0.05 {
+		t.Errorf("Jaccard()=%v, want about 0.33", got)
+	}
+
+	// Containment of a part in the whole.
+	part := NewFracMinHash(100)
+	part.Add(a[:50000], 21)
+	if got := part.Containment(fa); got != 1 {
+		t.Errorf("Containment(part, whole)=%v, want 1", got)
+	}
+	if got := fa.Containment(part); math.Abs(got-0.25) > 0.05 {
+		t.Errorf("Containment(whole, part)=%v, want about 0.25", got)
+	}
+	if !slices.IsSorted(fa.Hashes()) {
+		t.Errorf("Hashes() is not sorted")
+	}
+}
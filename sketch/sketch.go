@@ -0,0 +1,123 @@
+// Package sketch samples k-mers of sequences for fast comparison without
+// alignment.
+//
+// Minimizers and syncmers select a subset of the k-mers of a sequence, such
+// that similar sequences select similar k-mers. MinHash and FracMinHash
+// sketches summarize k-mer sets, and estimate Jaccard similarity and
+// containment between them.
+//
+// All functions work on canonical k-mers (see package kmer), so a sequence
+// and its reverse complement are sampled the same way.
+package sketch
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/fluhus/golgi/kmer"
+)
+
+// Hash returns a hash of a k-mer. The hash is invertible, so distinct k-mers
+// have distinct hashes, and it mixes all bits, so hash order is unrelated to
+// lexicographic order.
+func Hash(x kmer.Kmer) uint64 {
+	// Finalizer of MurmurHash3.
+	h := uint64(x)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// A k-mer at a position.
+type hashed struct {
+	pos  int
+	kmer kmer.Kmer
+	hash uint64
+}
+
+// Minimizers returns an iterator over the (w,k)-minimizers of seq and their
+// positions: in every w consecutive k-mers, the one with the lowest hash.
+// Each minimizer is yielded once, even if it is the lowest in several
+// windows. Ties are broken by position. Windows do not span characters other
+// than ACGT.
+func Minimizers(seq []byte, w, k int) iter.Seq2[int, kmer.Kmer] {
+	if w < 1 {
+		panic(fmt.Sprintf("bad w: %d", w))
+	}
+	return func(yield func(int, kmer.Kmer) bool) {
+		var window []hashed // Candidates with increasing hashes.
+		last := -2          // Position of the last k-mer.
+		run := 0            // Number of consecutive k-mers.
+		yielded := -1       // Position of the last yielded minimizer.
+		for pos, x := range kmer.AllCanonical(seq, k) {
+			if pos != last+1 {
+				window, run = window[:0], 0
+			}
+			last = pos
+			run++
+
+			h := hashed{pos, x, Hash(x)}
+			for len(window) > 0 && window[len(window)-1].hash > h.hash {
+				window = window[:len(window)-1]
+			}
+			window = append(window, h)
+			if window[0].pos <= pos-w {
+				window = window[1:]
+			}
+			if run < w || window[0].pos == yielded {
+				continue
+			}
+			yielded = window[0].pos
+			if !yield(window[0].pos, window[0].kmer) {
+				return
+			}
+		}
+	}
+}
+
+// ClosedSyncmers returns an iterator over the closed syncmers of seq and
+// their positions: k-mers whose lowest-hash s-mer is first or last.
+func ClosedSyncmers(seq []byte, k, s int) iter.Seq2[int, kmer.Kmer] {
+	return syncmers(seq, k, s, func(i int) bool {
+		return i == 0 || i == k-s
+	})
+}
+
+// OpenSyncmers returns an iterator over the open syncmers of seq and their
+// positions: k-mers whose lowest-hash s-mer is at offset t.
+func OpenSyncmers(seq []byte, k, s, t int) iter.Seq2[int, kmer.Kmer] {
+	if t < 0 || t > k-s {
+		panic(fmt.Sprintf("bad t: %d, want 0-%d", t, k-s))
+	}
+	return syncmers(seq, k, s, func(i int) bool { return i == t })
+}
+
+// Returns the k-mers whose lowest-hash s-mer offset satisfies f.
+func syncmers(seq []byte, k, s int, f func(int) bool) iter.Seq2[int,
+	kmer.Kmer] {
+	if s < 1 || s > k {
+		panic(fmt.Sprintf("bad s: %d, want 1-%d", s, k))
+	}
+	return func(yield func(int, kmer.Kmer) bool) {
+		// Hashes of s-mers by position. Positions in a valid k-mer have
+		// valid s-mers.
+		hashes := make([]uint64, max(len(seq)-s+1, 0))
+		for pos, x := range kmer.AllCanonical(seq, s) {
+			hashes[pos] = Hash(x)
+		}
+		for pos, x := range kmer.AllCanonical(seq, k) {
+			best := 0
+			for i := 1; i <= k-s; i++ {
+				if hashes[pos+i] < hashes[pos+best] {
+					best = i
+				}
+			}
+			if f(best) && !yield(pos, x) {
+				return
+			}
+		}
+	}
+}
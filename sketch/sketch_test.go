@@ -0,0 +1,145 @@
+package sketch
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/fluhus/golgi/kmer"
+)
+
+// Returns a random DNA sequence.
+func randomSeq(rnd *rand.Rand, n int, alphabet string) []byte {
+	seq := make([]byte, n)
+	for i := range seq {
+		seq[i] = alphabet[rnd.IntN(len(alphabet))]
+	}
+	return seq
+}
+
+// A k-mer and its position.
+type posKmer struct {
+	pos  int
+	kmer kmer.Kmer
+}
+
+// Collects the results of a k-mer iterator.
+func collect(seq func(func(int, kmer.Kmer) bool)) []posKmer {
+	var result []posKmer
+	for pos, x := range seq {
+		result = append(result, posKmer{pos, x})
+	}
+	return result
+}
+
+func TestHash(t *testing.T) {
+	seen := map[uint64]bool{}
+	for x := kmer.Kmer(0); x < 100000; x++ {
+		h := Hash(x)
+		if seen[h] {
+			t.Fatalf("Hash(%v)=%v is a duplicate", x, h)
+		}
+		seen[h] = true
+	}
+}
+
+func TestMinimizers(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for _, alphabet := range []string{"ACGT", "ACGTACGTACGTN"} {
+		seq := randomSeq(rnd, 2000, alphabet)
+		for _, wk := range [][2]int{{1, 5}, {5, 11}, {10, 15}} {
+			w, k := wk[0], wk[1]
+			got := collect(Minimizers(seq, w, k))
+			want := bruteMinimizers(seq, w, k)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Minimizers(w=%v,k=%v)=%v, want %v", w, k, got, want)
+			}
+		}
+	}
+}
+
+// Returns the minimizers of seq by checking every window.
+func bruteMinimizers(seq []byte, w, k int) []posKmer {
+	kmers := map[int]kmer.Kmer{}
+	for pos, x := range kmer.AllCanonical(seq, k) {
+		kmers[pos] = x
+	}
+	var result []posKmer
+	last := -1
+	for start := 0; start+w+k-1 <= len(seq); start++ {
+		best := -1
+		for pos := start; pos < start+w; pos++ {
+			x, ok := kmers[pos]
+			if !ok {
+				best = -1
+				break
+			}
+			if best == -1 || Hash(x) < Hash(kmers[best]) {
+				best = pos
+			}
+		}
+		if best != -1 && best != last {
+			result = append(result, posKmer{best, kmers[best]})
+			last = best
+		}
+	}
+	return result
+}
+
+func TestSyncmers(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	seq := randomSeq(rnd, 2000, "ACGTACGTACGTN")
+	k, s := 15, 5
+	closed := collect(ClosedSyncmers(seq, k, s))
+	open := collect(OpenSyncmers(seq, k, s, 2))
+	var wantClosed, wantOpen []posKmer
+	for pos, x := range kmer.AllCanonical(seq, k) {
+		best := -1
+		var bestHash uint64
+		for i := 0; i+s <= k; i++ {
+			y, _ := kmer.Encode(seq[pos+i : pos+i+s])
+			h := Hash(kmer.Canonical(y, s))
+			if best == -1 || h < bestHash {
+				best, bestHash = i, h
+			}
+		}
+		if best == 0 || best == k-s {
+			wantClosed = append(wantClosed, posKmer{pos, x})
+		}
+		if best == 2 {
+			wantOpen = append(wantOpen, posKmer{pos, x})
+		}
+	}
+	if !reflect.DeepEqual(closed, wantClosed) {
+		t.Errorf("ClosedSyncmers()=%v, want %v", closed, wantClosed)
+	}
+	if !reflect.DeepEqual(open, wantOpen) {
+		t.Errorf("OpenSyncmers()=%v, want %v", open, wantOpen)
+	}
+	// Density of closed syncmers is about 2/(k-s+1).
+	if d := float64(len(closed)) / float64(len(collect(kmer.AllCanonical(seq,
+		k)))); d < 0.1 || d > 0.3 {
+		t.Errorf("ClosedSyncmers() density=%v, want about 0.18", d)
+	}
+}
+
+func TestSyncmers_reverseComplement(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	seq := randomSeq(rnd, 500, "ACGT")
+	rc := make([]byte, len(seq))
+	for i, b := range seq {
+		rc[len(seq)-1-i] = map[byte]byte{'A': 'T', 'C': 'G', 'G': 'C',
+			'T': 'A'}[b]
+	}
+	a := map[kmer.Kmer]bool{}
+	for _, x := range ClosedSyncmers(seq, 21, 11) {
+		a[x] = true
+	}
+	b := map[kmer.Kmer]bool{}
+	for _, x := range ClosedSyncmers(rc, 21, 11) {
+		b[x] = true
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("ClosedSyncmers() of reverse complement differ")
+	}
+}
@@ -0,0 +1,238 @@
+package strdist
+
+// Affine-gap alignment with substitution matrices.
+
+import "math"
+
+// FreeEnds specifies which sequence ends may be left unaligned without
+// penalty, in global alignment.
+type FreeEnds uint8
+
+const (
+	FreeStart1 FreeEnds = 1 << iota // s1 may start after its first character
+	FreeEnd1                        // s1 may end before its last character
+	FreeStart2                      // s2 may start after its first character
+	FreeEnd2                        // s2 may end before its last character
+
+	// Global alignment, where both sequences are aligned end to end.
+	NoFreeEnds FreeEnds = 0
+
+	// Glocal alignment, where s1 is aligned end to end somewhere within s2.
+	Glocal = FreeStart2 | FreeEnd2
+
+	// Overlap alignment, where an end of one sequence overlaps an end of
+	// the other, or one contains the other.
+	Overlap = FreeStart1 | FreeEnd1 | FreeStart2 | FreeEnd2
+)
+
+// Aligner aligns sequences using a substitution matrix and affine gap
+// penalties. A gap of length L costs GapOpen+(L-1)*GapExtend. Scores are
+// higher for better alignments. An Aligner is safe for concurrent use.
+type Aligner struct {
+	Matrix    *ScoreMatrix // Substitution scores
+	GapOpen   int          // Penalty for the first character of a gap
+	GapExtend int          // Penalty for each following character of a gap
+	Local     bool         // Local (Smith-Waterman) alignment
+	FreeEnds  FreeEnds     // Ends that are free in global alignment
+}
+
+// Traceback pointers of affine alignment.
+const (
+	fromDiag  = 0 // H came from a match/mismatch
+	fromE     = 1 // H came from a deletion
+	fromF     = 2 // H came from an insertion
+	fromStart = 3 // H is 0, before the start of a local alignment
+	fromMask  = 3 // Mask of the H pointer
+	extendE   = 4 // E extends a deletion
+	extendF   = 8 // F extends an insertion
+)
+
+// Stands for minus infinity, leaving room for subtraction.
+const negInf = math.MinInt / 2
+
+// Align returns the best-scoring alignment of s1 and s2. If the aligner is
+// local and no part aligns with a positive score, the alignment is empty.
+func (a *Aligner) Align(s1, s2 []byte) *Alignment {
+	m, n := len(s1), len(s2)
+	local := a.Local
+	free := a.FreeEnds
+	if local {
+		free = 0
+	}
+	if m == 0 || n == 0 {
+		return a.alignEmpty(s1, s2, free)
+	}
+
+	// H is the best score, E ends with a deletion and F with an insertion.
+	// E is kept only for the current cell.
+	h := make([]int, n+1)
+	f := make([]int, n+1)
+	ptr := make([]byte, (m+1)*(n+1))
+
+	for j := 1; j <= n; j++ {
+		if local || free&FreeStart2 != 0 {
+			h[j] = 0
+		} else {
+			h[j] = -a.gapCost(j)
+		}
+		f[j] = negInf
+	}
+
+	best, bestI, bestJ := negInf, 0, 0
+	consider := func(score, i, j int) {
+		if score > best {
+			best, bestI, bestJ = score, i, j
+		}
+	}
+	for i := 1; i <= m; i++ {
+		// Column 0.
+		diag := h[0]
+		if local || free&FreeStart1 != 0 {
+			h[0] = 0
+		} else {
+			h[0] = -a.gapCost(i)
+		}
+		eRow := negInf
+		row := ptr[i*(n+1) : (i+1)*(n+1)]
+
+		for j := 1; j <= n; j++ {
+			var p byte
+
+			// Deletion: s2[j-1] against a gap.
+			eOpen, eExt := h[j-1]-a.GapOpen, eRow-a.GapExtend
+			if eExt > eOpen {
+				eRow = eExt
+				p |= extendE
+			} else {
+				eRow = eOpen
+			}
+
+			// Insertion: s1[i-1] against a gap.
+			fOpen, fExt := h[j]-a.GapOpen, f[j]-a.GapExtend
+			if fExt > fOpen {
+				f[j] = fExt
+				p |= extendF
+			} else {
+				f[j] = fOpen
+			}
+
+			score := diag + a.Matrix.Score(s1[i-1], s2[j-1])
+			src := byte(fromDiag)
+			if eRow > score {
+				score, src = eRow, fromE
+			}
+			if f[j] > score {
+				score, src = f[j], fromF
+			}
+			if local && score <= 0 {
+				score, src = 0, fromStart
+			}
+			diag = h[j]
+			h[j] = score
+			row[j] = p | src
+
+			if local {
+				consider(score, i, j)
+			}
+		}
+
+		if !local && free&FreeEnd1 != 0 && i < m {
+			consider(h[n], i, n)
+		}
+	}
+	if !local {
+		// Prefer aligning both ends fully.
+		if h[n] >= best {
+			best, bestI, bestJ = h[n], m, n
+		}
+		if free&FreeEnd2 != 0 {
+			for j := 0; j < n; j++ {
+				consider(h[j], m, j)
+			}
+		}
+	}
+
+	if local && best <= 0 {
+		return &Alignment{s1: s1, s2: s2}
+	}
+	return a.traceback(ptr, s1, s2, best, bestI, bestJ, free)
+}
+
+// Returns the alignment of sequences of which at least one is empty.
+func (a *Aligner) alignEmpty(s1, s2 []byte, free FreeEnds) *Alignment {
+	result := &Alignment{s1: s1, s2: s2}
+	if a.Local {
+		return result
+	}
+	if len(s1) > 0 && free&(FreeStart1|FreeEnd1) == 0 {
+		result.Score = -a.gapCost(len(s1))
+		result.End1 = len(s1)
+		for range s1 {
+			result.Ops = append(result.Ops, OpInsertion)
+		}
+	}
+	if len(s2) > 0 && free&(FreeStart2|FreeEnd2) == 0 {
+		result.Score = -a.gapCost(len(s2))
+		result.End2 = len(s2)
+		for range s2 {
+			result.Ops = append(result.Ops, OpDeletion)
+		}
+	}
+	return result
+}
+
+// Returns the cost of a gap of length n > 0.
+func (a *Aligner) gapCost(n int) int {
+	return a.GapOpen + (n-1)*a.GapExtend
+}
+
+// Returns the alignment that ends at the given position.
+func (a *Aligner) traceback(ptr []byte, s1, s2 []byte, score, i, j int,
+	free FreeEnds) *Alignment {
+	n := len(s2)
+	end1, end2 := i, j
+	var ops []Op
+	state := 0 // 0 for H, fromE or fromF.
+	for i > 0 && j > 0 {
+		p := ptr[i*(n+1)+j]
+		switch state {
+		case fromE:
+			ops = append(ops, OpDeletion)
+			j--
+			if p&extendE == 0 {
+				state = 0
+			}
+			continue
+		case fromF:
+			ops = append(ops, OpInsertion)
+			i--
+			if p&extendF == 0 {
+				state = 0
+			}
+			continue
+		}
+		switch p & fromMask {
+		case fromDiag:
+			ops = append(ops, alignOp(s1[i-1], s2[j-1]))
+			i--
+			j--
+		case fromStart:
+			reverseOps(ops)
+			return &Alignment{score, i, end1, j, end2, ops, s1, s2}
+		default:
+			state = int(p & fromMask)
+		}
+	}
+
+	// Remaining prefixes are either free or gaps.
+	if !a.Local {
+		for ; i > 0 && free&FreeStart1 == 0; i-- {
+			ops = append(ops, OpInsertion)
+		}
+		for ; j > 0 && free&FreeStart2 == 0; j-- {
+			ops = append(ops, OpDeletion)
+		}
+	}
+	reverseOps(ops)
+	return &Alignment{score, i, end1, j, end2, ops, s1, s2}
+}
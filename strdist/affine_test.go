@@ -0,0 +1,132 @@
+package strdist
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAligner(t *testing.T) {
+	dna := NewScoreMatrix("ACGT", 2, -3)
+	tests := []struct {
+		a      Aligner
+		s1, s2 string
+		score  int
+		cigar  string
+		coords [4]int
+	}{
+		{Aligner{Matrix: dna, GapOpen: 5, GapExtend: 2},
+			"ACGTACGT", "ACGTACGT", 16, "8M", [4]int{0, 8, 0, 8}},
+		{Aligner{Matrix: dna, GapOpen: 5, GapExtend: 2},
+			"ACGTCCACGT", "ACGTACGT", 16 - 7, "4M2I4M", [4]int{0, 10, 0, 8}},
+		{Aligner{Matrix: dna, GapOpen: 5, GapExtend: 2},
+			"ACGT", "GGACGTGG", 8 - 7 - 7, "2D4M2D", [4]int{0, 4, 0, 8}},
+		{Aligner{Matrix: dna, GapOpen: 5, GapExtend: 2, FreeEnds: Glocal},
+			"ACGT", "TTACGTTT", 8, "4M", [4]int{0, 4, 2, 6}},
+		{Aligner{Matrix: dna, GapOpen: 5, GapExtend: 2, FreeEnds: Overlap},
+			"GGGGACGT", "ACGTCCCC", 8, "4S4M", [4]int{4, 8, 0, 4}},
+		{Aligner{Matrix: dna, GapOpen: 5, GapExtend: 2, Local: true},
+			"GGGGACGTGG", "TTACGTTT", 8, "4S4M2S", [4]int{4, 8, 2, 6}},
+		{Aligner{Matrix: dna, GapOpen: 5, GapExtend: 2, Local: true},
+			"AAAA", "CCCC", 0, "4S", [4]int{0, 0, 0, 0}},
+		{Aligner{Matrix: dna, GapOpen: 5, GapExtend: 2},
+			"", "ACG", -9, "3D", [4]int{0, 0, 0, 3}},
+		{Aligner{Matrix: BLOSUM62, GapOpen: 11, GapExtend: 1},
+			"HEAGAWGHEE", "HEAGAWGHEE", 8 + 5 + 4 + 6 + 4 + 11 + 6 + 8 + 5 + 5,
+			"10M", [4]int{0, 10, 0, 10}},
+	}
+	for _, test := range tests {
+		a := test.a.Align([]byte(test.s1), []byte(test.s2))
+		if a.Score != test.score {
+			t.Errorf("Align(%q,%q).Score=%v, want %v",
+				test.s1, test.s2, a.Score, test.score)
+		}
+		if got := a.CIGAR(); got != test.cigar {
+			t.Errorf("Align(%q,%q).CIGAR()=%q, want %q",
+				test.s1, test.s2, got, test.cigar)
+		}
+		coords := [4]int{a.Start1, a.End1, a.Start2, a.End2}
+		if coords != test.coords {
+			t.Errorf("Align(%q,%q) coordinates=%v, want %v",
+				test.s1, test.s2, coords, test.coords)
+		}
+	}
+}
+
+func TestAligner_affine(t *testing.T) {
+	// One long gap is better than 2 short ones.
+	a := &Aligner{Matrix: NewScoreMatrix("ACGT", 1, -1), GapOpen: 4,
+		GapExtend: 1}
+	got := a.Align([]byte("AAACCCGGGTTT"), []byte("AAAGGGTTT"))
+	if want := "3M3I6M"; got.CIGAR() != want {
+		t.Errorf("CIGAR()=%q, want %q", got.CIGAR(), want)
+	}
+	if want := 9 - 6; got.Score != want {
+		t.Errorf("Score=%v, want %v", got.Score, want)
+	}
+}
+
+// With unit costs, global alignment is the negative edit distance.
+func TestAligner_editDistance(t *testing.T) {
+	a := &Aligner{Matrix: NewScoreMatrix("ACGT", 0, -1), GapOpen: 1,
+		GapExtend: 1}
+	for i := 0; i < 200; i++ {
+		s1, s2 := randomSeq(rand.Intn(20)), randomSeq(rand.Intn(20))
+		got := a.Align(s1, s2)
+		if want := -EditDistance(s1, s2); got.Score != want {
+			t.Fatalf("Align(%q,%q).Score=%v, want %v", s1, s2, got.Score,
+				want)
+		}
+		checkAlignment(t, "Align", got, s1, s2)
+		if got.Start1 != 0 || got.End1 != len(s1) ||
+			got.Start2 != 0 || got.End2 != len(s2) {
+			t.Fatalf("Align(%q,%q) is not global: %v", s1, s2, got.Ops)
+		}
+	}
+}
+
+// Checks that traceback scores match the reported scores.
+func TestAligner_random(t *testing.T) {
+	for _, free := range []FreeEnds{NoFreeEnds, Glocal, Overlap,
+		FreeStart1 | FreeEnd2} {
+		for _, local := range []bool{false, true} {
+			a := &Aligner{Matrix: EDNAFULL, GapOpen: 10, GapExtend: 1,
+				Local: local, FreeEnds: free}
+			for i := 0; i < 100; i++ {
+				s1 := randomSeq(rand.Intn(20))
+				s2 := mutate(s1, rand.Intn(5))
+				got := a.Align(s1, s2)
+				checkAlignment(t, "Align", got, s1, s2)
+				if score := rescore(a, got, s1, s2); score != got.Score {
+					t.Fatalf("Align(%q,%q,%v,%v).Score=%v, want %v",
+						s1, s2, local, free, got.Score, score)
+				}
+			}
+		}
+	}
+}
+
+// Returns the score of an alignment's operations.
+func rescore(a *Aligner, al *Alignment, s1, s2 []byte) int {
+	score := 0
+	var prev Op
+	for _, p := range al.Pairs() {
+		var op Op
+		switch {
+		case p[0] == -1:
+			op = OpDeletion
+		case p[1] == -1:
+			op = OpInsertion
+		default:
+			score += a.Matrix.Score(s1[p[0]], s2[p[1]])
+		}
+		if op != 0 {
+			if op == prev {
+				score -= a.GapExtend
+			} else {
+				score -= a.GapOpen
+			}
+		}
+		prev = op
+	}
+	return score
+}
@@ -0,0 +1,389 @@
+package strdist
+
+// Alignment traceback.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is an alignment operation, named like in extended CIGAR strings.
+type Op byte
+
+// Alignment operations. s1 is treated as the query and s2 as the reference.
+const (
+	OpMatch     Op = '=' // Equal characters
+	OpMismatch  Op = 'X' // Different characters
+	OpInsertion Op = 'I' // A character in s1 that is not in s2
+	OpDeletion  Op = 'D' // A character in s2 that is not in s1
+)
+
+// Alignment is an alignment of 2 sequences.
+type Alignment struct {
+	Score  int  // Score of the alignment, as defined by the aligning function
+	Start1 int  // Start of the aligned part of s1, inclusive
+	End1   int  // End of the aligned part of s1, exclusive
+	Start2 int  // Start of the aligned part of s2, inclusive
+	End2   int  // End of the aligned part of s2, exclusive
+	Ops    []Op // Operations from start to end
+
+	s1, s2 []byte // The aligned sequences.
+}
+
+// Pairs returns the aligned positions in s1 and s2, in order. Gaps are
+// represented by -1.
+func (a *Alignment) Pairs() [][2]int {
+	result := make([][2]int, 0, len(a.Ops))
+	i, j := a.Start1, a.Start2
+	for _, op := range a.Ops {
+		switch op {
+		case OpMatch, OpMismatch:
+			result = append(result, [2]int{i, j})
+			i++
+			j++
+		case OpInsertion:
+			result = append(result, [2]int{i, -1})
+			i++
+		case OpDeletion:
+			result = append(result, [2]int{-1, j})
+			j++
+		}
+	}
+	return result
+}
+
+// CIGAR returns the alignment as a CIGAR string, with M for matches and
+// mismatches. Parts of s1 outside the alignment are soft-clipped (S).
+func (a *Alignment) CIGAR() string {
+	return a.cigar(false)
+}
+
+// ExtendedCIGAR returns the alignment as a CIGAR string, with = for matches
+// and X for mismatches. Parts of s1 outside the alignment are soft-clipped
+// (S).
+func (a *Alignment) ExtendedCIGAR() string {
+	return a.cigar(true)
+}
+
+// Implements CIGAR and ExtendedCIGAR.
+func (a *Alignment) cigar(extended bool) string {
+	buf := &strings.Builder{}
+	if a.Start1 > 0 {
+		fmt.Fprintf(buf, "%dS", a.Start1)
+	}
+	for i := 0; i < len(a.Ops); {
+		op := a.Ops[i]
+		j := i + 1
+		for j < len(a.Ops) && (a.Ops[j] == op || !extended &&
+			isAligned(a.Ops[j]) && isAligned(op)) {
+			j++
+		}
+		if !extended && isAligned(op) {
+			op = 'M'
+		}
+		fmt.Fprintf(buf, "%d%c", j-i, op)
+		i = j
+	}
+	if a.End1 < len(a.s1) {
+		fmt.Fprintf(buf, "%dS", len(a.s1)-a.End1)
+	}
+	return buf.String()
+}
+
+// Returns whether op aligns a character to a character.
+func isAligned(op Op) bool {
+	return op == OpMatch || op == OpMismatch
+}
+
+// String returns a three-line view of the aligned parts, with '|' for
+// matches and '-' for gaps:
+//
+//	ACGT-ACG
+//	|| | |||
+//	ACTTAACG
+func (a *Alignment) String() string {
+	var l1, mid, l2 []byte
+	for _, p := range a.Pairs() {
+		switch {
+		case p[1] == -1:
+			l1, mid, l2 = append(l1, a.s1[p[0]]), append(mid, ' '),
+				append(l2, '-')
+		case p[0] == -1:
+			l1, mid, l2 = append(l1, '-'), append(mid, ' '),
+				append(l2, a.s2[p[1]])
+		default:
+			c := byte(' ')
+			if a.s1[p[0]] == a.s2[p[1]] {
+				c = '|'
+			}
+			l1, mid, l2 = append(l1, a.s1[p[0]]), append(mid, c),
+				append(l2, a.s2[p[1]])
+		}
+	}
+	return string(l1) + "\n" + string(mid) + "\n" + string(l2)
+}
+
+// Returns a match or mismatch operation for the given characters.
+func alignOp(a, b byte) Op {
+	if a == b {
+		return OpMatch
+	}
+	return OpMismatch
+}
+
+// Reverses the order of operations.
+func reverseOps(ops []Op) {
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+}
+
+// ----- EDIT DISTANCE ---------------------------------------------------------
+
+// EditAlignment returns an alignment of 2 byte arrays with the minimal edit
+// distance. The score is the edit distance.
+func EditAlignment(s1, s2 []byte) *Alignment {
+	m, n := len(s1), len(s2)
+	mat := make([][]int, m+1)
+	for i := range mat {
+		mat[i] = make([]int, n+1)
+		mat[i][0] = i
+	}
+	for j := range mat[0] {
+		mat[0][j] = j
+	}
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= n; j++ {
+			replacement := mat[i-1][j-1]
+			if s1[i-1] != s2[j-1] {
+				replacement++
+			}
+			mat[i][j] = minInt(replacement, mat[i-1][j]+1, mat[i][j-1]+1)
+		}
+	}
+
+	// Traceback.
+	var ops []Op
+	i, j := m, n
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && mat[i][j] == mat[i-1][j-1]+
+			boolToInt(s1[i-1] != s2[j-1]):
+			ops = append(ops, alignOp(s1[i-1], s2[j-1]))
+			i--
+			j--
+		case i > 0 && mat[i][j] == mat[i-1][j]+1:
+			ops = append(ops, OpInsertion)
+			i--
+		default:
+			ops = append(ops, OpDeletion)
+			j--
+		}
+	}
+	reverseOps(ops)
+	return &Alignment{mat[m][n], 0, m, 0, n, ops, s1, s2}
+}
+
+// Returns 1 for true and 0 for false.
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// EditAlignmentStrings returns an alignment of 2 strings with the minimal
+// edit distance.
+func EditAlignmentStrings(s1, s2 string) *Alignment {
+	return EditAlignment([]byte(s1), []byte(s2))
+}
+
+// ----- BLAST -----------------------------------------------------------------
+
+// Step types of Blast matrices.
+const (
+	stepMM    = 0 // Match/mismatch
+	stepG1    = 1 // Gap in sequence 1
+	stepG2    = 2 // Gap in sequence 2
+	stepStart = 3 // Match/mismatch that starts a local alignment
+)
+
+// Returns the score of extending a gap from the given block.
+func gapScore(from blastBlock, step int, scores BlastScores) int {
+	if from.step == step {
+		return from.score + scores.GapExtend
+	}
+	return from.score + scores.GapOpen
+}
+
+// BlastAlignment returns the alignment found by BlastDistance. The score is
+// the Blast distance.
+func BlastAlignment(s1, s2 []byte, scores BlastScores) *Alignment {
+	// Follow BlastDistance, where rows are the shorter sequence.
+	swapped := len(s1) > len(s2)
+	if swapped {
+		s1, s2 = s2, s1
+	}
+	m, n := len(s1), len(s2)
+	mat := make([][]blastBlock, m+1)
+	for i := range mat {
+		mat[i] = make([]blastBlock, n+1)
+	}
+	for col := 0; col <= n; col++ {
+		for row := 0; row <= m; row++ {
+			switch {
+			case col == 0 && row == 0:
+				mat[row][col] = blastBlock{0, stepMM}
+			case col == 0:
+				mat[row][col] = blastBlock{gapScore(mat[row-1][col], stepG2,
+					scores), stepG2}
+			case row == 0:
+				mat[row][col] = blastBlock{gapScore(mat[row][col-1], stepG1,
+					scores), stepG1}
+			default:
+				mat[row][col] = blastCell(mat, row, col, s1, s2, scores, false)
+			}
+		}
+	}
+
+	ops := blastTraceback(mat, m, n, s1, s2)
+	result := &Alignment{mat[m][n].score, 0, m, 0, n, ops, s1, s2}
+	if swapped {
+		result.swap()
+	}
+	return result
+}
+
+// BlastAlignmentStrings returns the alignment found by BlastDistanceStrings.
+func BlastAlignmentStrings(s1, s2 string, scores BlastScores) *Alignment {
+	return BlastAlignment([]byte(s1), []byte(s2), scores)
+}
+
+// LocalBlastAlignment returns the alignment found by LocalBlastDistance,
+// with the coordinates of the aligned parts. The score is the local Blast
+// distance. If no part aligns with a negative score, the alignment is empty.
+func LocalBlastAlignment(s1, s2 []byte, scores BlastScores) *Alignment {
+	m, n := len(s1), len(s2)
+	mat := make([][]blastBlock, m+1)
+	for i := range mat {
+		mat[i] = make([]blastBlock, n+1)
+	}
+	best, bestRow, bestCol := 0, 0, 0
+	for col := 0; col <= n; col++ {
+		for row := 0; row <= m; row++ {
+			switch {
+			case col == 0 && row == 0:
+				mat[row][col] = blastBlock{0, stepMM}
+			case col == 0:
+				mat[row][col] = blastBlock{gapScore(mat[row-1][col], stepG2,
+					scores), stepG2}
+			case row == 0:
+				mat[row][col] = blastBlock{gapScore(mat[row][col-1], stepG1,
+					scores), stepG1}
+			default:
+				mat[row][col] = blastCell(mat, row, col, s1, s2, scores, true)
+				if mat[row][col].score < best {
+					best, bestRow, bestCol = mat[row][col].score, row, col
+				}
+			}
+		}
+	}
+
+	if best == 0 {
+		return &Alignment{s1: s1, s2: s2}
+	}
+	ops := blastTraceback(mat, bestRow, bestCol, s1, s2)
+	startRow, startCol := bestRow, bestCol
+	for _, op := range ops {
+		if op != OpDeletion {
+			startRow--
+		}
+		if op != OpInsertion {
+			startCol--
+		}
+	}
+	return &Alignment{best, startRow, bestRow, startCol, bestCol, ops, s1, s2}
+}
+
+// LocalBlastAlignmentStrings returns the alignment found by
+// LocalBlastDistanceStrings.
+func LocalBlastAlignmentStrings(s1, s2 string,
+	scores BlastScores) *Alignment {
+	return LocalBlastAlignment([]byte(s1), []byte(s2), scores)
+}
+
+// Returns the value of an inner cell of a Blast matrix, choosing between
+// the same options in the same order as BlastDistance and
+// LocalBlastDistance.
+func blastCell(mat [][]blastBlock, row, col int, s1, s2 []byte,
+	scores BlastScores, local bool) blastBlock {
+	gap1 := gapScore(mat[row][col-1], stepG1, scores)
+	gap2 := gapScore(mat[row-1][col], stepG2, scores)
+	newmatch := scores.Mismatch
+	if s1[row-1] == s2[col-1] {
+		newmatch = scores.Match
+	}
+	match := mat[row-1][col-1].score + newmatch
+
+	if local {
+		switch minInt(gap1, gap2, match, newmatch) {
+		case match:
+			return blastBlock{match, stepMM}
+		case newmatch:
+			return blastBlock{newmatch, stepStart}
+		case gap1:
+			return blastBlock{gap1, stepG1}
+		default:
+			return blastBlock{gap2, stepG2}
+		}
+	}
+	switch minInt(gap1, gap2, match) {
+	case match:
+		return blastBlock{match, stepMM}
+	case gap1:
+		return blastBlock{gap1, stepG1}
+	default:
+		return blastBlock{gap2, stepG2}
+	}
+}
+
+// Returns the operations that lead to the given cell, from the alignment
+// start.
+func blastTraceback(mat [][]blastBlock, row, col int, s1, s2 []byte) []Op {
+	var ops []Op
+	for row > 0 || col > 0 {
+		step := mat[row][col].step
+		switch step {
+		case stepMM, stepStart:
+			ops = append(ops, alignOp(s1[row-1], s2[col-1]))
+			row--
+			col--
+		case stepG1:
+			ops = append(ops, OpDeletion)
+			col--
+		case stepG2:
+			ops = append(ops, OpInsertion)
+			row--
+		}
+		if step == stepStart {
+			break
+		}
+	}
+	reverseOps(ops)
+	return ops
+}
+
+// Swaps the roles of s1 and s2.
+func (a *Alignment) swap() {
+	a.s1, a.s2 = a.s2, a.s1
+	a.Start1, a.Start2 = a.Start2, a.Start1
+	a.End1, a.End2 = a.End2, a.End1
+	for i, op := range a.Ops {
+		switch op {
+		case OpInsertion:
+			a.Ops[i] = OpDeletion
+		case OpDeletion:
+			a.Ops[i] = OpInsertion
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package strdist
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestEditAlignment(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		score  int
+		cigar  string
+		ext    string
+		view   string
+	}{
+		{"", "", 0, "", "", "\n\n"},
+		{"ACGT", "ACGT", 0, "4M", "4=", "ACGT\n||||\nACGT"},
+		{"ACGT", "AGGT", 1, "4M", "1=1X2=", "ACGT\n| ||\nAGGT"},
+		{"ACGT", "AGT", 1, "1M1I2M", "1=1I2=", "ACGT\n| ||\nA-GT"},
+		{"AGT", "ACGT", 1, "1M1D2M", "1=1D2=", "A-GT\n| ||\nACGT"},
+		{"", "AC", 2, "2D", "2D", "--\n  \nAC"},
+	}
+	for _, test := range tests {
+		a := EditAlignmentStrings(test.s1, test.s2)
+		if a.Score != test.score {
+			t.Errorf("EditAlignment(%q,%q).Score=%v, want %v",
+				test.s1, test.s2, a.Score, test.score)
+		}
+		if got := a.CIGAR(); got != test.cigar {
+			t.Errorf("EditAlignment(%q,%q).CIGAR()=%q, want %q",
+				test.s1, test.s2, got, test.cigar)
+		}
+		if got := a.ExtendedCIGAR(); got != test.ext {
+			t.Errorf("EditAlignment(%q,%q).ExtendedCIGAR()=%q, want %q",
+				test.s1, test.s2, got, test.ext)
+		}
+		if got := a.String(); got != test.view {
+			t.Errorf("EditAlignment(%q,%q).String()=%q, want %q",
+				test.s1, test.s2, got, test.view)
+		}
+	}
+}
+
+func TestAlignment_pairs(t *testing.T) {
+	a := EditAlignmentStrings("ACGTT", "AGTTC")
+	want := [][2]int{{0, 0}, {1, -1}, {2, 1}, {3, 2}, {4, 3}, {-1, 4}}
+	if got := a.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pairs()=%v, want %v", got, want)
+	}
+}
+
+func TestLocalBlastAlignment(t *testing.T) {
+	a := LocalBlastAlignmentStrings("TTTTACGTACGTTTTT", "GGACGTACGGG",
+		BlastDefaultScores())
+	if a.Score != -14 {
+		t.Errorf("Score=%v, want -14", a.Score)
+	}
+	if a.Start1 != 4 || a.End1 != 11 || a.Start2 != 2 || a.End2 != 9 {
+		t.Errorf("coordinates=%v,%v,%v,%v, want 4,11,2,9",
+			a.Start1, a.End1, a.Start2, a.End2)
+	}
+	if got, want := a.CIGAR(), "4S7M5S"; got != want {
+		t.Errorf("CIGAR()=%q, want %q", got, want)
+	}
+
+	a = LocalBlastAlignmentStrings("AAAA", "CCCC", BlastDefaultScores())
+	if a.Score != 0 || len(a.Ops) != 0 {
+		t.Errorf("LocalBlastAlignment(AAAA,CCCC)=%v,%v, want 0,[]",
+			a.Score, a.Ops)
+	}
+}
+
+// Checks that alignments agree with the distance functions and with the
+// input sequences.
+func TestAlignment_random(t *testing.T) {
+	scores := BlastDefaultScores()
+	for i := 0; i < 200; i++ {
+		s1, s2 := randomSeq(rand.Intn(20)), randomSeq(rand.Intn(20))
+		checks := []struct {
+			name  string
+			a     *Alignment
+			score int
+		}{
+			{"EditAlignment", EditAlignment(s1, s2), EditDistance(s1, s2)},
+			{"BlastAlignment", BlastAlignment(s1, s2, scores),
+				BlastDistance(s1, s2, scores)},
+			{"LocalBlastAlignment", LocalBlastAlignment(s1, s2, scores),
+				LocalBlastDistance(s1, s2, scores)},
+		}
+		for _, c := range checks {
+			if c.a.Score != c.score {
+				t.Fatalf("%s(%q,%q).Score=%v, want %v",
+					c.name, s1, s2, c.a.Score, c.score)
+			}
+			checkAlignment(t, c.name, c.a, s1, s2)
+		}
+	}
+}
+
+// Fails if the alignment's operations do not match its sequences and
+// coordinates.
+func checkAlignment(t *testing.T, name string, a *Alignment, s1, s2 []byte) {
+	i, j := a.Start1, a.Start2
+	for k, p := range a.Pairs() {
+		if p[0] != -1 {
+			if p[0] != i {
+				t.Fatalf("%s(%q,%q): bad s1 position %v, want %v",
+					name, s1, s2, p[0], i)
+			}
+			i++
+		}
+		if p[1] != -1 {
+			if p[1] != j {
+				t.Fatalf("%s(%q,%q): bad s2 position %v, want %v",
+					name, s1, s2, p[1], j)
+			}
+			j++
+		}
+		if p[0] != -1 && p[1] != -1 {
+			if (s1[p[0]] == s2[p[1]]) != (a.Ops[k] == OpMatch) {
+				t.Fatalf("%s(%q,%q): bad op at %v", name, s1, s2, p)
+			}
+		}
+	}
+	if i != a.End1 || j != a.End2 {
+		t.Fatalf("%s(%q,%q): ends at %v,%v, want %v,%v",
+			name, s1, s2, i, j, a.End1, a.End2)
+	}
+}
+
+// Returns a random DNA sequence of the given length.
+func randomSeq(n int) []byte {
+	result := make([]byte, n)
+	for i := range result {
+		result[i] = "ACGT"[rand.Intn(4)]
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package strdist
+
+// Substitution matrices.
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ScoreMatrix holds substitution scores between characters. Higher scores
+// are better. Lower-case letters get the scores of their upper-case
+// versions, unless the matrix defines them. Characters that are not in the
+// matrix get the matrix's lowest score.
+type ScoreMatrix struct {
+	index   [256]int // Index of each character in scores, or -1.
+	n       int      // Number of characters.
+	scores  []int    // Row-major scores.
+	missing int      // Score of characters that are not in the matrix.
+}
+
+// Score returns the score of substituting a with b.
+func (m *ScoreMatrix) Score(a, b byte) int {
+	i, j := m.index[a], m.index[b]
+	if i == -1 || j == -1 {
+		return m.missing
+	}
+	return m.scores[i*m.n+j]
+}
+
+// NewScoreMatrix returns a matrix over the given characters, with match for
+// equal characters and mismatch for different ones.
+func NewScoreMatrix(chars string, match, mismatch int) *ScoreMatrix {
+	n := len(chars)
+	scores := make([]int, n*n)
+	for i := range n {
+		for j := range n {
+			if chars[i] == chars[j] {
+				scores[i*n+j] = match
+			} else {
+				scores[i*n+j] = mismatch
+			}
+		}
+	}
+	return newScoreMatrix(chars, scores)
+}
+
+// Returns a matrix with the given characters and row-major scores.
+func newScoreMatrix(chars string, scores []int) *ScoreMatrix {
+	m := &ScoreMatrix{n: len(chars), scores: scores}
+	for i := range m.index {
+		m.index[i] = -1
+	}
+	for i := range chars {
+		m.index[chars[i]] = i
+	}
+	for i := range chars {
+		lower := strings.ToLower(chars[i : i+1])[0]
+		if m.index[lower] == -1 {
+			m.index[lower] = i
+		}
+	}
+	m.missing = minInt(scores...)
+	return m
+}
+
+// ReadScoreMatrix reads a matrix in the NCBI format, as used for BLOSUM and
+// PAM matrices. Lines starting with '#' are ignored. The first line has the
+// column characters, and each following line has a row character and its
+// scores.
+func ReadScoreMatrix(r io.Reader) (*ScoreMatrix, error) {
+	var chars string
+	var scores []int
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0][0] == '#' {
+			continue
+		}
+		for _, f := range fields {
+			if len(f) != 1 && chars == "" {
+				return nil, fmt.Errorf("line %d: bad character: %q", line, f)
+			}
+		}
+		if chars == "" {
+			chars = strings.Join(fields, "")
+			continue
+		}
+
+		row := len(scores) / len(chars)
+		if row == len(chars) {
+			return nil, fmt.Errorf("line %d: too many rows", line)
+		}
+		if len(fields) != len(chars)+1 {
+			return nil, fmt.Errorf("line %d: bad number of fields: %d, "+
+				"expected %d", line, len(fields), len(chars)+1)
+		}
+		if fields[0] != chars[row:row+1] {
+			return nil, fmt.Errorf("line %d: bad row character: %q, "+
+				"expected %q", line, fields[0], chars[row:row+1])
+		}
+		for _, f := range fields[1:] {
+			score, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			scores = append(scores, score)
+		}
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+	if chars == "" {
+		return nil, fmt.Errorf("empty matrix")
+	}
+	if len(scores) != len(chars)*len(chars) {
+		return nil, fmt.Errorf("bad number of rows: %d, expected %d",
+			len(scores)/len(chars), len(chars))
+	}
+	return newScoreMatrix(chars, scores), nil
+}
+
+// LoadScoreMatrix reads a matrix file in the NCBI format.
+func LoadScoreMatrix(file string) (*ScoreMatrix, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadScoreMatrix(f)
+}
+
+// Standard substitution matrices. Should not be modified.
+var (
+	// BLOSUM62 is the default matrix for protein alignment.
+	BLOSUM62 = mustReadEmbedded("BLOSUM62")
+
+	// PAM250 is a protein matrix for distant homologs.
+	PAM250 = mustReadEmbedded("PAM250")
+
+	// EDNAFULL (NUC.4.4) is a DNA matrix with IUPAC codes.
+	EDNAFULL = mustReadEmbedded("EDNAFULL")
+)
+
+//go:embed matrices
+var matrixFiles embed.FS
+
+// Reads an embedded matrix file. Panics on error.
+func mustReadEmbedded(name string) *ScoreMatrix {
+	f, err := matrixFiles.Open("matrices/" + name)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	m, err := ReadScoreMatrix(f)
+	if err != nil {
+		panic(fmt.Sprintf("matrix %s: %v", name, err))
+	}
+	return m
+}
@@ -0,0 +1,75 @@
+package strdist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStandardMatrices(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *ScoreMatrix
+		a, b byte
+		want int
+	}{
+		{"BLOSUM62", BLOSUM62, 'W', 'W', 11},
+		{"BLOSUM62", BLOSUM62, 'A', 'R', -1},
+		{"BLOSUM62", BLOSUM62, 'c', 'C', 9},
+		{"BLOSUM62", BLOSUM62, 'A', '!', -4},
+		{"PAM250", PAM250, 'W', 'W', 17},
+		{"PAM250", PAM250, 'C', 'W', -8},
+		{"EDNAFULL", EDNAFULL, 'A', 'A', 5},
+		{"EDNAFULL", EDNAFULL, 'A', 'R', 1},
+		{"EDNAFULL", EDNAFULL, 'U', 't', 5},
+		{"EDNAFULL", EDNAFULL, 'N', 'N', -1},
+	}
+	for _, test := range tests {
+		if got := test.m.Score(test.a, test.b); got != test.want {
+			t.Errorf("%s.Score(%c,%c)=%v, want %v",
+				test.name, test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestStandardMatrices_symmetric(t *testing.T) {
+	for name, m := range map[string]*ScoreMatrix{
+		"BLOSUM62": BLOSUM62, "PAM250": PAM250, "EDNAFULL": EDNAFULL} {
+		for a := 0; a < 256; a++ {
+			for b := 0; b < a; b++ {
+				if m.Score(byte(a), byte(b)) != m.Score(byte(b), byte(a)) {
+					t.Errorf("%s.Score(%c,%c)!=Score(%c,%c)",
+						name, a, b, b, a)
+				}
+			}
+		}
+	}
+}
+
+func TestReadScoreMatrix(t *testing.T) {
+	input := "# Comment\n  A  B\nA  1 -2\nB -2  3\n"
+	m, err := ReadScoreMatrix(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadScoreMatrix(%q) failed: %v", input, err)
+	}
+	if got := m.Score('B', 'B'); got != 3 {
+		t.Errorf("Score(B,B)=%v, want 3", got)
+	}
+	if got := m.Score('a', 'b'); got != -2 {
+		t.Errorf("Score(a,b)=%v, want -2", got)
+	}
+
+	bad := []string{
+		"",
+		"  A  B\nA  1 -2\n",
+		"  A  B\nA  1 -2\nB -2\n",
+		"  A  B\nA  1 -2\nC -2  3\n",
+		"  A  B\nA  1 -2\nB -2  x\n",
+		"  A  BC\nA  1 -2\nB -2  3\n",
+		"  A  B\nA  1 -2\nB -2  3\nB -2  3\n",
+	}
+	for _, input := range bad {
+		if _, err := ReadScoreMatrix(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadScoreMatrix(%q) succeeded, want error", input)
+		}
+	}
+}
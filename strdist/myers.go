@@ -0,0 +1,196 @@
+package strdist
+
+// Bit-parallel and banded edit distance.
+
+// MyersPattern is a pattern preprocessed for Myers' bit-parallel edit
+// distance. Patterns of any length are supported; patterns of up to 64
+// characters are the fastest. A MyersPattern is safe for concurrent use.
+type MyersPattern struct {
+	pattern []byte
+	blocks  int      // Number of 64-bit blocks.
+	peq     []uint64 // Match bit-vectors, by character and then block.
+}
+
+// NewMyersPattern returns a preprocessed pattern.
+func NewMyersPattern(pattern []byte) *MyersPattern {
+	blocks := (len(pattern) + 63) / 64
+	peq := make([]uint64, 256*blocks)
+	for i, c := range pattern {
+		peq[int(c)*blocks+i/64] |= 1 << (i % 64)
+	}
+	return &MyersPattern{pattern, blocks, peq}
+}
+
+// Len returns the length of the pattern.
+func (p *MyersPattern) Len() int {
+	return len(p.pattern)
+}
+
+// Distance returns the edit distance between the pattern and text. Gives
+// the same result as EditDistance.
+func (p *MyersPattern) Distance(text []byte) int {
+	m := len(p.pattern)
+	if m == 0 {
+		return len(text)
+	}
+	if p.blocks == 1 {
+		return myers64(p.peq, m, text)
+	}
+
+	pv := make([]uint64, p.blocks)
+	mv := make([]uint64, p.blocks)
+	for i := range pv {
+		pv[i] = ^uint64(0)
+	}
+	high := uint64(1) << ((m - 1) % 64)
+	score := m
+	for _, c := range text {
+		eq := p.peq[int(c)*p.blocks : int(c)*p.blocks+p.blocks]
+		h := 1 // First row of the matrix increases by 1 each column.
+		for b := range pv {
+			mask := uint64(1) << 63
+			if b == p.blocks-1 {
+				mask = high
+			}
+			h = advanceBlock(&pv[b], &mv[b], eq[b], h, mask)
+		}
+		score += h
+	}
+	return score
+}
+
+// Advances a block of the bit-parallel matrix by one column, with the given
+// horizontal input delta. Returns the horizontal output delta at the bit in
+// mask.
+func advanceBlock(pv, mv *uint64, eq uint64, hin int, mask uint64) int {
+	xv := eq | *mv
+	if hin < 0 {
+		eq |= 1
+	}
+	xh := (((eq & *pv) + *pv) ^ *pv) | eq
+	ph := *mv | ^(xh | *pv)
+	mh := *pv & xh
+	hout := 0
+	if ph&mask != 0 {
+		hout = 1
+	} else if mh&mask != 0 {
+		hout = -1
+	}
+	ph <<= 1
+	mh <<= 1
+	if hin < 0 {
+		mh |= 1
+	} else if hin > 0 {
+		ph |= 1
+	}
+	*pv = mh | ^(xv | ph)
+	*mv = ph & xv
+	return hout
+}
+
+// Returns the edit distance between a pattern of 1 to 64 characters and
+// text. peq holds the match bit-vectors of the pattern by character.
+func myers64(peq []uint64, m int, text []byte) int {
+	pv, mv := ^uint64(0), uint64(0)
+	high := uint64(1) << (m - 1)
+	score := m
+	for _, c := range text {
+		eq := peq[c]
+		xv := eq | mv
+		xh := (((eq & pv) + pv) ^ pv) | eq
+		ph := mv | ^(xh | pv)
+		mh := pv & xh
+		if ph&high != 0 {
+			score++
+		} else if mh&high != 0 {
+			score--
+		}
+		ph = ph<<1 | 1
+		mh <<= 1
+		pv = mh | ^(xv | ph)
+		mv = ph & xv
+	}
+	return score
+}
+
+// MyersDistance returns the edit distance between 2 byte arrays, using
+// Myers' bit-parallel algorithm. Gives the same result as EditDistance, in
+// O(n*m/64) time.
+func MyersDistance(s1, s2 []byte) int {
+	if len(s1) > len(s2) {
+		s1, s2 = s2, s1
+	}
+	if len(s1) == 0 {
+		return len(s2)
+	}
+	if len(s1) > 64 {
+		return NewMyersPattern(s1).Distance(s2)
+	}
+	var peq [256]uint64
+	for i, c := range s1 {
+		peq[c] |= 1 << i
+	}
+	return myers64(peq[:], len(s1), s2)
+}
+
+// MyersDistanceStrings returns the edit distance between 2 strings, using
+// Myers' bit-parallel algorithm.
+func MyersDistanceStrings(s1, s2 string) int {
+	return MyersDistance([]byte(s1), []byte(s2))
+}
+
+// BandedEditDistance returns the edit distance between 2 byte arrays if it
+// is at most k, or k+1 if it is greater. Only a band of width 2k+1 around
+// the diagonal is calculated, and calculation stops as soon as the distance
+// is known to exceed k, in O(k*min(n,m)) time.
+func BandedEditDistance(s1, s2 []byte, k int) int {
+	if k < 0 {
+		panic("k must be non-negative")
+	}
+	if len(s1) > len(s2) {
+		s1, s2 = s2, s1
+	}
+	m, n := len(s1), len(s2)
+	if n-m > k {
+		return k + 1
+	}
+	k = min(k, n) // The distance cannot exceed n.
+
+	// Values are capped at k+1, which stands for "more than k".
+	prev := make([]int, n+1)
+	next := make([]int, n+1)
+	for j := range prev {
+		prev[j] = min(j, k+1)
+	}
+	for i := 1; i <= m; i++ {
+		lo, hi := max(1, i-k), min(n, i+k)
+		next[lo-1] = k + 1
+		if lo == 1 {
+			next[0] = min(i, k+1)
+		}
+		rowMin := next[lo-1]
+		for j := lo; j <= hi; j++ {
+			d := prev[j-1]
+			if s1[i-1] != s2[j-1] {
+				d++
+			}
+			d = min(d, prev[j]+1, next[j-1]+1, k+1)
+			next[j] = d
+			rowMin = min(rowMin, d)
+		}
+		if hi < n {
+			next[hi+1] = k + 1
+		}
+		if rowMin > k {
+			return k + 1
+		}
+		prev, next = next, prev
+	}
+	return prev[n]
+}
+
+// BandedEditDistanceStrings returns the edit distance between 2 strings if
+// it is at most k, or k+1 if it is greater.
+func BandedEditDistanceStrings(s1, s2 string, k int) int {
+	return BandedEditDistance([]byte(s1), []byte(s2), k)
+}
@@ -0,0 +1,106 @@
+package strdist
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestMyersDistance(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"ACGT", "ACGT", 0},
+		{"ACGT", "TGCA", 4},
+	}
+	for _, test := range tests {
+		if got := MyersDistanceStrings(test.s1, test.s2); got != test.want {
+			t.Errorf("MyersDistance(%q,%q)=%v, want %v",
+				test.s1, test.s2, got, test.want)
+		}
+	}
+}
+
+func TestMyersDistance_random(t *testing.T) {
+	for _, n := range []int{10, 63, 64, 65, 130, 200} {
+		for i := 0; i < 50; i++ {
+			s1 := randomSeq(rand.Intn(n + 1))
+			s2 := mutate(s1, rand.Intn(n/4+1))
+			want := EditDistance(s1, s2)
+			if got := MyersDistance(s1, s2); got != want {
+				t.Fatalf("MyersDistance(%q,%q)=%v, want %v", s1, s2, got, want)
+			}
+			if got := NewMyersPattern(s2).Distance(s1); got != want {
+				t.Fatalf("Distance(%q,%q)=%v, want %v", s2, s1, got, want)
+			}
+		}
+	}
+}
+
+func TestBandedEditDistance(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		s1 := randomSeq(rand.Intn(30))
+		s2 := mutate(s1, rand.Intn(6))
+		k := rand.Intn(6)
+		want := min(EditDistance(s1, s2), k+1)
+		if got := BandedEditDistance(s1, s2, k); got != want {
+			t.Fatalf("BandedEditDistance(%q,%q,%v)=%v, want %v",
+				s1, s2, k, got, want)
+		}
+	}
+}
+
+// Returns a copy of s with n random substitutions, insertions and deletions.
+func mutate(s []byte, n int) []byte {
+	s = append([]byte(nil), s...)
+	for i := 0; i < n; i++ {
+		pos := rand.Intn(len(s) + 1)
+		switch rand.Intn(3) {
+		case 0:
+			if pos < len(s) {
+				s[pos] = "ACGT"[rand.Intn(4)]
+			}
+		case 1:
+			s = append(s[:pos], append([]byte{"ACGT"[rand.Intn(4)]},
+				s[pos:]...)...)
+		case 2:
+			if pos < len(s) {
+				s = append(s[:pos], s[pos+1:]...)
+			}
+		}
+	}
+	return s
+}
+
+func BenchmarkEditDistance(b *testing.B) {
+	for _, n := range []int{16, 150} {
+		s1 := randomSeq(n)
+		s2 := mutate(s1, n/10)
+		b.Run(fmt.Sprintf("full-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				EditDistance(s1, s2)
+			}
+		})
+		b.Run(fmt.Sprintf("myers-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MyersDistance(s1, s2)
+			}
+		})
+		p := NewMyersPattern(s1)
+		b.Run(fmt.Sprintf("pattern-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p.Distance(s2)
+			}
+		})
+		b.Run(fmt.Sprintf("banded-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				BandedEditDistance(s1, s2, 2)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package strdist
+
+// Approximate pattern search.
+
+// Match is an approximate occurrence of a pattern in a text.
+type Match struct {
+	End      int // Position in the text after the last matched character
+	Distance int // Distance between the pattern and the matched substring
+}
+
+// SearchHamming returns the occurrences of pattern in text with up to k
+// mismatches, by end position. Each match starts at End-len(pattern).
+func SearchHamming(pattern, text []byte, k int) []Match {
+	var result []Match
+	if k < 0 {
+		return nil
+	}
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		d := 0
+		for j := range pattern {
+			if pattern[j] != text[i+j] {
+				d++
+				if d > k {
+					break
+				}
+			}
+		}
+		if d <= k {
+			result = append(result, Match{i + len(pattern), d})
+		}
+	}
+	return result
+}
+
+// SearchHammingStrings returns the occurrences of pattern in text with up to
+// k mismatches, by end position.
+func SearchHammingStrings(pattern, text string, k int) []Match {
+	return SearchHamming([]byte(pattern), []byte(text), k)
+}
+
+// SearchEdit returns the end positions in text where a substring ends that
+// is within edit distance k of pattern, with the smallest such distance.
+// An occurrence may be reported at several adjacent end positions.
+func SearchEdit(pattern, text []byte, k int) []Match {
+	return NewMyersPattern(pattern).Search(text, k)
+}
+
+// SearchEditStrings returns the end positions in text where a substring
+// ends that is within edit distance k of pattern.
+func SearchEditStrings(pattern, text string, k int) []Match {
+	return SearchEdit([]byte(pattern), []byte(text), k)
+}
+
+// Search returns the end positions in text where a substring ends that is
+// within edit distance k of the pattern, with the smallest such distance.
+// An occurrence may be reported at several adjacent end positions.
+func (p *MyersPattern) Search(text []byte, k int) []Match {
+	if k < 0 {
+		return nil
+	}
+	m := len(p.pattern)
+	var result []Match
+	if m <= k {
+		// The empty substring at each position is close enough.
+		result = append(result, Match{0, m})
+	}
+	if m == 0 {
+		for j := range text {
+			result = append(result, Match{j + 1, 0})
+		}
+		return result
+	}
+
+	pv := make([]uint64, p.blocks)
+	mv := make([]uint64, p.blocks)
+	for i := range pv {
+		pv[i] = ^uint64(0)
+	}
+	high := uint64(1) << ((m - 1) % 64)
+	score := m
+	for j, c := range text {
+		eq := p.peq[int(c)*p.blocks : int(c)*p.blocks+p.blocks]
+		h := 0 // A match can start anywhere, so the first row is all 0.
+		for b := range pv {
+			mask := uint64(1) << 63
+			if b == p.blocks-1 {
+				mask = high
+			}
+			h = advanceBlock(&pv[b], &mv[b], eq[b], h, mask)
+		}
+		score += h
+		if score <= k {
+			result = append(result, Match{j + 1, score})
+		}
+	}
+	return result
+}
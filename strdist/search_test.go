@@ -0,0 +1,84 @@
+package strdist
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSearchHamming(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		k             int
+		want          []Match
+	}{
+		{"ACG", "TTACGTTACTT", 0, []Match{{5, 0}}},
+		{"ACG", "TTACGTTACTT", 1, []Match{{5, 0}, {10, 1}}},
+		{"ACG", "AC", 3, nil},
+		{"ACG", "ACG", -1, nil},
+	}
+	for _, test := range tests {
+		got := SearchHammingStrings(test.pattern, test.text, test.k)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SearchHamming(%q,%q,%v)=%v, want %v",
+				test.pattern, test.text, test.k, got, test.want)
+		}
+	}
+}
+
+func TestSearchEdit(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		k             int
+		want          []Match
+	}{
+		{"ACGT", "TTACGTTT", 0, []Match{{6, 0}}},
+		{"ACGT", "TTAGTTT", 1, []Match{{5, 1}}},
+		{"ACGT", "TTACCGTTT", 1, []Match{{7, 1}}},
+		{"ACGT", "TTACCGTTT", 2,
+			[]Match{{4, 2}, {5, 2}, {6, 2}, {7, 1}, {8, 2}}},
+		{"AC", "GG", 2, []Match{{0, 2}, {1, 2}, {2, 2}}},
+		{"", "GG", 0, []Match{{0, 0}, {1, 0}, {2, 0}}},
+	}
+	for _, test := range tests {
+		got := SearchEditStrings(test.pattern, test.text, test.k)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SearchEdit(%q,%q,%v)=%v, want %v",
+				test.pattern, test.text, test.k, got, test.want)
+		}
+	}
+}
+
+// Compares search results with a naive calculation.
+func TestSearchEdit_random(t *testing.T) {
+	for _, m := range []int{5, 64, 70} {
+		for i := 0; i < 10; i++ {
+			pattern := randomSeq(m)
+			text := append(append(randomSeq(rand.Intn(30)),
+				mutate(pattern, rand.Intn(4))...), randomSeq(rand.Intn(30))...)
+			k := rand.Intn(m/2 + 1)
+			got := SearchEdit(pattern, text, k)
+			if want := naiveSearchEdit(pattern, text, k); !reflect.DeepEqual(
+				got, want) {
+				t.Fatalf("SearchEdit(%q,%q,%v)=%v, want %v",
+					pattern, text, k, got, want)
+			}
+		}
+	}
+}
+
+// Returns the matches of pattern in text by calculating the edit distance
+// of every substring.
+func naiveSearchEdit(pattern, text []byte, k int) []Match {
+	var result []Match
+	for end := 0; end <= len(text); end++ {
+		best := len(pattern)
+		for start := 0; start <= end; start++ {
+			best = min(best, EditDistance(pattern, text[start:end]))
+		}
+		if best <= k {
+			result = append(result, Match{end, best})
+		}
+	}
+	return result
+}
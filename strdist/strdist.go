@@ -7,7 +7,6 @@ import (
 	"github.com/fluhus/golgi/sequtil"
 )
 
-// TODO(amit): Add tests.
 // TODO(amit): Remove bigram functions?
 
 // Computes the edit distance for 2 byte arrays.
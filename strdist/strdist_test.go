@@ -0,0 +1,105 @@
+package strdist
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"ACGT", "AGT", 1},
+	}
+	for _, test := range tests {
+		if got := EditDistanceStrings(test.s1, test.s2); got != test.want {
+			t.Errorf("EditDistance(%q,%q)=%v, want %v",
+				test.s1, test.s2, got, test.want)
+		}
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   int
+	}{
+		{"", "", 0},
+		{"ACGT", "ACGT", 0},
+		{"ACGT", "ACCT", 1},
+		{"ACGT", "AC", 2},
+		{"AC", "TGCA", 4},
+	}
+	for _, test := range tests {
+		if got := HammingDistanceStrings(test.s1, test.s2); got != test.want {
+			t.Errorf("HammingDistance(%q,%q)=%v, want %v",
+				test.s1, test.s2, got, test.want)
+		}
+	}
+}
+
+func TestNgramDistance(t *testing.T) {
+	tests := []struct {
+		n      int
+		s1, s2 string
+		want   int
+	}{
+		{2, "ACGT", "ACGT", 0},
+		{2, "ACGT", "CGTA", 1},
+		{1, "AACC", "ACCC", 1},
+		{1, "AAAA", "CCCC", 4},
+	}
+	for _, test := range tests {
+		got := NgramDistanceStrings(test.n, test.s1, test.s2)
+		if got != test.want {
+			t.Errorf("NgramDistance(%v,%q,%q)=%v, want %v",
+				test.n, test.s1, test.s2, got, test.want)
+		}
+	}
+}
+
+func TestBlastDistance(t *testing.T) {
+	scores := BlastDefaultScores()
+	tests := []struct {
+		s1, s2 string
+		want   int
+	}{
+		{"", "", 0},
+		{"ACGT", "ACGT", -8},
+		{"ACGT", "ACCT", -3},
+		{"ACGT", "AGT", -1},
+		{"AT", "ACCCT", 5 + 2 + 2 - 4},
+	}
+	for _, test := range tests {
+		got := BlastDistanceStrings(test.s1, test.s2, scores)
+		if got != test.want {
+			t.Errorf("BlastDistance(%q,%q)=%v, want %v",
+				test.s1, test.s2, got, test.want)
+		}
+	}
+}
+
+func TestLocalBlastDistance(t *testing.T) {
+	scores := BlastDefaultScores()
+	tests := []struct {
+		s1, s2 string
+		want   int
+	}{
+		{"", "", 0},
+		{"AAAA", "CCCC", 0},
+		{"ACGT", "TTACGTTT", -8},
+		{"GGGACGTGGG", "ACGT", -8},
+		{"ACGTACGT", "ACGTTCGT", -11},
+	}
+	for _, test := range tests {
+		got := LocalBlastDistance([]byte(test.s1), []byte(test.s2), scores)
+		if got != test.want {
+			t.Errorf("LocalBlastDistance(%q,%q)=%v, want %v",
+				test.s1, test.s2, got, test.want)
+		}
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/fluhus/golgi/genome"
 )
 
 func main() {
@@ -112,6 +114,14 @@ func tileFile(file string, tileSize int, t *fileTiles) error {
 			continue
 		}
 
+		// Check if within chromosome.
+		if args.genome != nil {
+			err := args.genome.Validate(chr, pos, pos+1)
+			if err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+		}
+
 		methd := int(total * ratio)
 
 		// Create chromosome.
@@ -166,8 +176,16 @@ func printTiles(outFile string, t []*fileTiles, tileSize int) error {
 	// Go over tiles.
 	for _, chr := range collectChroms(t) {
 		for _, pos := range collectPoss(t, chr) {
-			// Print chromosome and position.
-			fmt.Fprintf(bout, "%s\t%d\t%d", chr, pos, pos+tileSize)
+			// Print chromosome and position, without passing the chromosome's
+			// end.
+			end := pos + tileSize
+			if args.genome != nil {
+				length, _ := args.genome.Length(chr)
+				if end > length {
+					end = length
+				}
+			}
+			fmt.Fprintf(bout, "%s\t%d\t%d", chr, pos, end)
 
 			for _, file := range t {
 				var value float64
@@ -196,6 +214,7 @@ func printTiles(outFile string, t []*fileTiles, tileSize int) error {
 }
 
 // Returns a sorted list of all chromosomes that appear in the given tiles.
+// Chromosomes are in genome order if a genome was given.
 func collectChroms(t []*fileTiles) []string {
 	chrmap := make(map[string]struct{})
 	for _, tt := range t {
@@ -209,7 +228,13 @@ func collectChroms(t []*fileTiles) []string {
 		chrs = append(chrs, chr)
 	}
 
-	sort.Sort(sort.StringSlice(chrs))
+	if args.genome != nil {
+		sort.Slice(chrs, func(i, j int) bool {
+			return args.genome.Less(chrs[i], chrs[j])
+		})
+	} else {
+		sort.Sort(sort.StringSlice(chrs))
+	}
 
 	return chrs
 }
@@ -244,6 +269,7 @@ var args struct {
 	outFile     string
 	tileSize    int
 	minCoverage *int
+	genome      *genome.Genome
 	err         error
 }
 
@@ -254,9 +280,18 @@ func parseArguments() {
 	size := flag.Int("s", 100, "Length of tile.")
 	args.minCoverage = flag.Int("c", -1,
 		"Minimal coverage for a base to be included. Default is no limit.")
+	genomeFile := flag.String("g", "", "Genome file (.chrom.sizes, .fai or "+
+		"SAM header), for clipping tiles at chromosome ends. Default is none.")
 
 	flag.Parse()
 
+	if *genomeFile != "" {
+		args.genome, args.err = genome.Load(*genomeFile)
+		if args.err != nil {
+			return
+		}
+	}
+
 	// Split input files into groups.
 	for _, list := range flag.Args() {
 		split := strings.Split(list, ",")
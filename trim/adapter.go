@@ -0,0 +1,202 @@
+package trim
+
+// Handles trimming of adapters.
+
+// AdapterEnd trims an adapter from the end (3') of reads, with everything
+// after it.
+//
+// If ErrorRate is 0, it takes the first exact occurrence of the whole
+// adapter anywhere in the read. If there is none, it takes the longest
+// overlap that includes the adapter's start and the read's end, and has at
+// most n/Tolerance mismatches, where n is the length of the overlap, or none
+// if Tolerance is not positive. Indels are not allowed.
+//
+// If ErrorRate is positive, the adapter is searched anywhere in the read,
+// allowing mismatches and indels. See ErrorRate for details.
+//
+// Overlaps shorter than MinOverlap are ignored. Case insensitive.
+type AdapterEnd struct {
+	Adapter    []byte
+	Tolerance  int // Used when ErrorRate is 0; no mismatches if <= 0
+	MinOverlap int // Minimal overlap of a partial adapter with the read
+
+	// Maximal number of errors (mismatches and indels) per adapter base.
+	// The adapter matches where it occurs whole, or where a prefix of it
+	// occurs at the read's end, with at most floor(n*ErrorRate) errors,
+	// where n is the number of adapter bases. Whole occurrences are
+	// preferred over partial ones, then fewer errors, then earlier ones.
+	ErrorRate float64
+}
+
+// Name returns "adapter-end".
+func (a *AdapterEnd) Name() string {
+	return "adapter-end"
+}
+
+// Trim returns the part of the read without the adapter.
+func (a *AdapterEnd) Trim(seq, quals []byte) (start, end int) {
+	if a.ErrorRate > 0 {
+		pos := findAdapter(seqView{seq, false}, seqView{a.Adapter, false},
+			a.ErrorRate, a.MinOverlap)
+		return 0, pos
+	}
+	if pos := findExact(seqView{seq, false},
+		seqView{a.Adapter, false}); pos != -1 {
+		return 0, pos
+	}
+	for n := min(len(seq), len(a.Adapter)); n >= max(a.MinOverlap, 1); n-- {
+		if matches(seq[len(seq)-n:], a.Adapter[:n],
+			mismatches(n, a.Tolerance)) {
+			return 0, len(seq) - n
+		}
+	}
+	return 0, len(seq)
+}
+
+// AdapterStart trims an adapter from the start (5') of reads, with
+// everything before it. It works like AdapterEnd in the opposite direction:
+// partial overlaps include the adapter's end and the read's start.
+type AdapterStart struct {
+	Adapter    []byte
+	Tolerance  int     // Used when ErrorRate is 0; no mismatches if <= 0
+	MinOverlap int     // Minimal overlap of a partial adapter with the read
+	ErrorRate  float64 // Like in AdapterEnd
+}
+
+// Name returns "adapter-start".
+func (a *AdapterStart) Name() string {
+	return "adapter-start"
+}
+
+// Trim returns the part of the read without the adapter.
+func (a *AdapterStart) Trim(seq, quals []byte) (start, end int) {
+	if a.ErrorRate > 0 {
+		pos := findAdapter(seqView{seq, true}, seqView{a.Adapter, true},
+			a.ErrorRate, a.MinOverlap)
+		return len(seq) - pos, len(seq)
+	}
+	if pos := findExact(seqView{seq, true},
+		seqView{a.Adapter, true}); pos != -1 {
+		return len(seq) - pos, len(seq)
+	}
+	for n := min(len(seq), len(a.Adapter)); n >= max(a.MinOverlap, 1); n-- {
+		if matches(seq[:n], a.Adapter[len(a.Adapter)-n:],
+			mismatches(n, a.Tolerance)) {
+			return n, len(seq)
+		}
+	}
+	return 0, len(seq)
+}
+
+// Returns whether a and b have at most k mismatches. Assumes equal lengths.
+func matches(a, b []byte, k int) bool {
+	for i := range a {
+		if upper(a[i]) != upper(b[i]) {
+			k--
+			if k < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Returns the ASCII upper case for the given byte.
+func upper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - ('a' - 'A')
+	}
+	return b
+}
+
+// A sequence that can be viewed in reverse without copying.
+type seqView struct {
+	seq      []byte
+	reversed bool
+}
+
+// Returns the i'th upper-case character of the view.
+func (s seqView) at(i int) byte {
+	if s.reversed {
+		return upper(s.seq[len(s.seq)-1-i])
+	}
+	return upper(s.seq[i])
+}
+
+// Returns the position in the read where the first exact occurrence of the
+// adapter starts, or -1 if there is none.
+func findExact(read, adapter seqView) int {
+	n, m := len(read.seq), len(adapter.seq)
+	if m == 0 {
+		return -1
+	}
+	for i := 0; i <= n-m; i++ {
+		j := 0
+		for j < m && read.at(i+j) == adapter.at(j) {
+			j++
+		}
+		if j == m {
+			return i
+		}
+	}
+	return -1
+}
+
+// A cell of the adapter search matrix.
+type adapterCell struct {
+	errors int // Edit distance
+	start  int // Read position where the alignment starts
+}
+
+// Returns the position in the read where the best adapter match starts, or
+// the read's length if none is found. The read's prefix is free, and the
+// adapter's suffix is free where the read ends.
+func findAdapter(read, adapter seqView, rate float64, minOverlap int) int {
+	n, m := len(read.seq), len(adapter.seq)
+	maxErrors := func(i int) int { return int(float64(i) * rate) }
+
+	// Columns of the matrix, by adapter position.
+	col := make([]adapterCell, m+1)
+	for i := range col {
+		col[i] = adapterCell{i, 0}
+	}
+
+	best := adapterCell{0, n}
+	bestLen := 0
+	consider := func(c adapterCell, length int) {
+		if length < max(minOverlap, 1) || c.errors > maxErrors(length) {
+			return
+		}
+		if length > bestLen || length == bestLen &&
+			(c.errors < best.errors ||
+				c.errors == best.errors && c.start < best.start) {
+			best, bestLen = c, length
+		}
+	}
+
+	consider(col[m], m)
+	for j := 1; j <= n; j++ {
+		diag := col[0]
+		col[0] = adapterCell{0, j}
+		c := read.at(j - 1)
+		for i := 1; i <= m; i++ {
+			next := diag
+			if adapter.at(i-1) != c {
+				next.errors++
+			}
+			if col[i].errors+1 < next.errors { // Extra read base.
+				next = adapterCell{col[i].errors + 1, col[i].start}
+			}
+			if col[i-1].errors+1 < next.errors { // Missing read base.
+				next = adapterCell{col[i-1].errors + 1, col[i-1].start}
+			}
+			diag = col[i]
+			col[i] = next
+		}
+		consider(col[m], m)
+	}
+	for i := 1; i < m; i++ {
+		consider(col[i], i)
+	}
+	return best.start
+}
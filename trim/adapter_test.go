@@ -0,0 +1,157 @@
+package trim
+
+import (
+	"testing"
+
+	"github.com/fluhus/golgi/formats/fastq"
+)
+
+func Test_NoMatch(t *testing.T) {
+	fq := &fastq.Fastq{}
+	fq.Sequence = []byte("ACTAGGTTCA")
+	fq.Quals = []byte("IIIIIIIIII")
+
+	apply(fq, &AdapterEnd{Adapter: []byte("CCCA"), Tolerance: 5})
+
+	if string(fq.Sequence) != "ACTAGGTTCA" {
+		t.Errorf("bad trimming: got '%s' expected '%s'",
+			string(fq.Sequence), "ACTAGGTTCA")
+	}
+}
+
+func Test_Match(t *testing.T) {
+	fq := &fastq.Fastq{}
+	fq.Sequence = []byte("ACTAGGTTCA")
+	fq.Quals = []byte("ABCDEFGHIJ")
+
+	apply(fq, &AdapterEnd{Adapter: []byte("TCAAAAAA"), Tolerance: 5})
+
+	if string(fq.Sequence) != "ACTAGGT" {
+		t.Errorf("bad trimming: got '%s' expected '%s'",
+			string(fq.Sequence), "ACTAGGT")
+	}
+	if string(fq.Quals) != "ABCDEFG" {
+		t.Errorf("bad trimming: got quals '%s' expected '%s'",
+			string(fq.Quals), "ABCDEFG")
+	}
+}
+
+func Test_MatchWithErrors(t *testing.T) {
+	fq := &fastq.Fastq{}
+	fq.Sequence = []byte("ACTAGGTTCATTTAGCGCTTAA")
+	fq.Quals = []byte("1234567891234567891234")
+
+	apply(fq, &AdapterEnd{Adapter: []byte("TAGCCCTTAGGTAAT"), Tolerance: 5})
+
+	if string(fq.Sequence) != "ACTAGGTTCATT" {
+		t.Errorf("bad trimming: got '%s' expected '%s'",
+			string(fq.Sequence), "ACTAGGTTCATT")
+	}
+	if string(fq.Quals) != "123456789123" {
+		t.Errorf("bad trimming: got quals '%s' expected '%s'",
+			string(fq.Quals), "123456789123")
+	}
+}
+
+func Test_MatchWithTooManyErrors(t *testing.T) {
+	fq := &fastq.Fastq{}
+	fq.Sequence = []byte("ACTAGGTTCATTTAGCGCTTAA")
+	fq.Quals = []byte("1234567891234567891234")
+
+	apply(fq, &AdapterEnd{Adapter: []byte("GAGCCCTTAGGTAAT"), Tolerance: 5})
+
+	if string(fq.Sequence) != "ACTAGGTTCATTTAGCGCTTAA" {
+		t.Errorf("bad trimming: got '%s' expected '%s'",
+			string(fq.Sequence), "ACTAGGTTCATTTAGCGCTTAA")
+	}
+	if string(fq.Quals) != "1234567891234567891234" {
+		t.Errorf("bad trimming: got quals '%s' expected '%s'",
+			string(fq.Quals), "1234567891234567891234")
+	}
+}
+
+func Test_TrimStart(t *testing.T) {
+	fq := &fastq.Fastq{}
+	fq.Sequence = []byte("ACTAGGTTCATTTAGCGCTTAA")
+	fq.Quals = []byte("1234567891234567891234")
+
+	apply(fq, &AdapterStart{Adapter: []byte("GGCACTA"), Tolerance: 5})
+
+	if string(fq.Sequence) != "GGTTCATTTAGCGCTTAA" {
+		t.Errorf("bad trimming: got '%s' expected '%s'",
+			string(fq.Sequence), "GGTTCATTTAGCGCTTAA")
+	}
+	if string(fq.Quals) != "567891234567891234" {
+		t.Errorf("bad trimming: got quals '%s' expected '%s'",
+			string(fq.Quals), "567891234567891234")
+	}
+}
+
+func Test_ErrorRate(t *testing.T) {
+	tests := []struct {
+		step Step
+		seq  string
+		want string
+	}{
+		// Whole adapter inside the read, followed by other bases.
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), ErrorRate: 0.1},
+			"ACTAGGTTCAAGATCGGAAGAGCTTTTTTT", "ACTAGGTTCA"},
+		// With a deletion.
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), ErrorRate: 0.1},
+			"ACTAGGTTCAAGATCGAAGAGCTTTTTTT", "ACTAGGTTCA"},
+		// With an insertion, in lower case.
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), ErrorRate: 0.1},
+			"actaggttcaagatcggtaagagctttttt", "actaggttca"},
+		// Too many errors.
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), ErrorRate: 0.1},
+			"ACTAGGTTCAAGTTCGAAGAGCTTTTTTT", "ACTAGGTTCAAGTTCGAAGAGCTTTTTTT"},
+		// Partial adapter at the end.
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), ErrorRate: 0.1},
+			"ACTAGGTTCAAGATCG", "ACTAGGTTCA"},
+		// Partial adapter shorter than the minimal overlap.
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), ErrorRate: 0.1,
+			MinOverlap: 3}, "ACTAGGTTCAAG", "ACTAGGTTCAAG"},
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), ErrorRate: 0.1,
+			MinOverlap: 3}, "ACTAGGTTCAAGA", "ACTAGGTTCA"},
+		// Whole adapter at the start.
+		{&AdapterStart{Adapter: []byte("GGCACTA"), ErrorRate: 0.15},
+			"TTTGGCACTAGGTTCATTT", "GGTTCATTT"},
+		{&AdapterStart{Adapter: []byte("GGCACTA"), ErrorRate: 0.15},
+			"TTTGGCCTAGGTTCATTT", "GGTTCATTT"},
+		// Partial adapter at the start.
+		{&AdapterStart{Adapter: []byte("GGCACTA"), ErrorRate: 0.15},
+			"ACTAGGTTCATTT", "GGTTCATTT"},
+		// Legacy mode with a minimal overlap.
+		{&AdapterEnd{Adapter: []byte("TCAAAAAA"), Tolerance: 5,
+			MinOverlap: 4}, "ACTAGGTTCA", "ACTAGGTTCA"},
+		// Exact whole adapter inside the read, without an error rate.
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), Tolerance: 10},
+			"ACTAGGTTCAagatcggaagagcTTTTTTT", "ACTAGGTTCA"},
+		{&AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), Tolerance: 10},
+			"ACTAGGTTCAAGATCGAAGAGCTTTTTTT", "ACTAGGTTCAAGATCGAAGAGCTTTTTTT"},
+		{&AdapterStart{Adapter: []byte("GGCACTA"), Tolerance: 5},
+			"TTTGGCACTAGGTTCATTT", "GGTTCATTT"},
+		// Zero values allow no mismatches.
+		{&AdapterEnd{Adapter: []byte("TCAAAAAA")}, "ACTAGGTTCA",
+			"ACTAGGT"},
+		{&AdapterEnd{Adapter: []byte("TCAAAAAA")}, "ACTAGGTTCTAA",
+			"ACTAGGTTCTAA"},
+		{&AdapterStart{Adapter: []byte("GGCACTA")}, "ACTAGGTTCA",
+			"GGTTCA"},
+		{&Adapters{Adapters: []Adapter{{"a", []byte("TCAAAAAA")}}},
+			"ACTAGGTTCA", "ACTAGGT"},
+	}
+	for _, test := range tests {
+		fq := &fastq.Fastq{Sequence: []byte(test.seq),
+			Quals: []byte(test.seq)}
+		apply(fq, test.step)
+		if string(fq.Sequence) != test.want {
+			t.Errorf("bad trimming of %q: got %q expected %q",
+				test.seq, string(fq.Sequence), test.want)
+		}
+		if string(fq.Quals) != test.want {
+			t.Errorf("bad trimming of %q: got quals %q expected %q",
+				test.seq, string(fq.Quals), test.want)
+		}
+	}
+}
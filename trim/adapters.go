@@ -0,0 +1,136 @@
+package trim
+
+// Handles multiple adapters and adapter detection.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fluhus/golgi/formats/fasta"
+)
+
+// Adapter is a named adapter sequence.
+type Adapter struct {
+	Name     string
+	Sequence []byte
+}
+
+// Adapters trims the best of several adapters from one end of reads. Each
+// adapter is matched like in AdapterEnd, or AdapterStart if Start is true,
+// and the one that trims the most bases is used. Ties go to the earlier
+// adapter. Reads are labeled by the name of the trimmed adapter.
+type Adapters struct {
+	Adapters   []Adapter
+	Start      bool    // Trim from the start (5') instead of the end (3')
+	Tolerance  int     // Like in AdapterEnd
+	MinOverlap int     // Like in AdapterEnd
+	ErrorRate  float64 // Like in AdapterEnd
+}
+
+// Name returns "adapter-start" or "adapter-end".
+func (a *Adapters) Name() string {
+	if a.Start {
+		return "adapter-start"
+	}
+	return "adapter-end"
+}
+
+// Trim returns the part of the read without the best adapter.
+func (a *Adapters) Trim(seq, quals []byte) (start, end int) {
+	start, end, _ = a.TrimLabeled(seq, quals)
+	return start, end
+}
+
+// TrimLabeled returns the part of the read without the best adapter, and
+// the adapter's name.
+func (a *Adapters) TrimLabeled(seq, quals []byte) (start, end int,
+	label string) {
+	start, end = 0, len(seq)
+	for _, ad := range a.Adapters {
+		var s, e int
+		if a.Start {
+			s, e = (&AdapterStart{ad.Sequence, a.Tolerance, a.MinOverlap,
+				a.ErrorRate}).Trim(seq, quals)
+		} else {
+			s, e = (&AdapterEnd{ad.Sequence, a.Tolerance, a.MinOverlap,
+				a.ErrorRate}).Trim(seq, quals)
+		}
+		if e-s < end-start {
+			start, end, label = s, e, ad.Name
+		}
+	}
+	return start, end, label
+}
+
+// ReadAdapters reads adapters from a fasta stream. Names are the fasta
+// titles.
+func ReadAdapters(r io.Reader) ([]Adapter, error) {
+	var result []Adapter
+	for fa, err := range fasta.NewReader(r).All() {
+		if err != nil {
+			return nil, err
+		}
+		if len(fa.Sequence) == 0 {
+			return nil, fmt.Errorf("adapter %q is empty", fa.Name)
+		}
+		result = append(result, Adapter{string(fa.Name), fa.Sequence})
+	}
+	return result, nil
+}
+
+// LoadAdapters reads adapters from a fasta file.
+func LoadAdapters(file string) ([]Adapter, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAdapters(f)
+}
+
+// KnownAdapters are common 3' adapters for detection.
+var KnownAdapters = []Adapter{
+	{"Illumina TruSeq", []byte("AGATCGGAAGAGC")},
+	{"Nextera", []byte("CTGTCTCTTATACACATCT")},
+	{"Illumina small RNA", []byte("TGGAATTCTCGG")},
+}
+
+// Length of adapter prefixes that are counted for detection.
+const detectLength = 12
+
+// CountAdapters returns the number of reads that contain the first 12
+// bases of each adapter, or the whole adapter if shorter. Case insensitive.
+func CountAdapters(seqs [][]byte, adapters []Adapter) []int {
+	prefixes := make([][]byte, len(adapters))
+	for i, a := range adapters {
+		prefixes[i] = bytes.ToUpper(a.Sequence[:min(len(a.Sequence),
+			detectLength)])
+	}
+	result := make([]int, len(adapters))
+	for _, seq := range seqs {
+		seq = bytes.ToUpper(seq)
+		for i, p := range prefixes {
+			if bytes.Contains(seq, p) {
+				result[i]++
+			}
+		}
+	}
+	return result
+}
+
+// DetectAdapter returns the index of the adapter that occurs in the most
+// reads, like Trim Galore does. Ties go to the earlier adapter. Returns -1
+// if none occurs. Typically used on the first reads of a file with
+// KnownAdapters.
+func DetectAdapter(seqs [][]byte, adapters []Adapter) int {
+	best := -1
+	bestCount := 0
+	for i, n := range CountAdapters(seqs, adapters) {
+		if n > bestCount {
+			best, bestCount = i, n
+		}
+	}
+	return best
+}
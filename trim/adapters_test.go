@@ -0,0 +1,87 @@
+package trim
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/golgi/formats/fastq"
+)
+
+func TestAdapters(t *testing.T) {
+	a := &Adapters{Adapters: []Adapter{
+		{"a1", []byte("AGATCGGAAGAGC")},
+		{"a2", []byte("CTGTCTCTTATACACATCT")},
+	}, Tolerance: 10}
+	tests := []struct {
+		seq   string
+		want  string
+		label string
+	}{
+		{"ACGTACGTAGATCGGAAG", "ACGTACGT", "a1"},
+		{"ACGTACGTCTGTCTCTTAT", "ACGTACGT", "a2"},
+		{"ACGTACGTCTGTCTCTTATACACATCTTT", "ACGTACGT", "a2"},
+		{"ACGTACGTTTTTTT", "ACGTACGTTTTTTT", ""},
+	}
+	for _, test := range tests {
+		start, end, label := a.TrimLabeled([]byte(test.seq), nil)
+		if got := test.seq[start:end]; got != test.want ||
+			label != test.label {
+			t.Errorf("TrimLabeled(%q)=%q,%q, want %q,%q",
+				test.seq, got, label, test.want, test.label)
+		}
+	}
+}
+
+func TestAdapters_stats(t *testing.T) {
+	tr := &Trimmer{Steps: []Step{&Adapters{Adapters: []Adapter{
+		{"a1", []byte("AGATCGGAAGAGC")},
+		{"a2", []byte("TGGAATTCTCGG")},
+	}, ErrorRate: 0.1, MinOverlap: 3}}, Stats: &Stats{}}
+	for _, seq := range []string{"ACGTACGTAGATCGGAAGAGCTT",
+		"ACGTACGTTGGAATTCTCGGAA", "ACGTACGTTGGAATTCTCGGAA", "ACGTACGT"} {
+		tr.Trim(&fastq.Fastq{Sequence: []byte(seq), Quals: []byte(seq)})
+	}
+	want := map[string]int{"a1": 1, "a2": 2}
+	if got := tr.Stats.Steps[0].Labels; !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels=%v, want %v", got, want)
+	}
+}
+
+func TestReadAdapters(t *testing.T) {
+	input := ">TruSeq\nAGATCGGAAGAGC\n>Nextera\nCTGTCTCTTATA\nCACATCT\n"
+	got, err := ReadAdapters(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAdapters(%q) failed: %v", input, err)
+	}
+	want := []Adapter{
+		{"TruSeq", []byte("AGATCGGAAGAGC")},
+		{"Nextera", []byte("CTGTCTCTTATACACATCT")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAdapters(%q)=%v, want %v", input, got, want)
+	}
+
+	if _, err := ReadAdapters(strings.NewReader(">a\n>b\nACGT\n")); err == nil {
+		t.Errorf("ReadAdapters() succeeded for empty adapter, want error")
+	}
+}
+
+func TestDetectAdapter(t *testing.T) {
+	reads := [][]byte{
+		[]byte("ACGTACGTACCTGTCTCTTATACACATCT"),
+		[]byte("ACGTACGTACGTTTGGAATTCTCGGTT"),
+		[]byte("acgtacgtactgtctcttatacacatct"),
+		[]byte("ACGTACGTACGTACGTACGTACGTACGTAC"),
+	}
+	if got := CountAdapters(reads, KnownAdapters); !reflect.DeepEqual(got,
+		[]int{0, 2, 1}) {
+		t.Errorf("CountAdapters()=%v, want [0 2 1]", got)
+	}
+	if got := DetectAdapter(reads, KnownAdapters); got != 1 {
+		t.Errorf("DetectAdapter()=%v, want 1", got)
+	}
+	if got := DetectAdapter(reads[3:], KnownAdapters); got != -1 {
+		t.Errorf("DetectAdapter()=%v, want -1", got)
+	}
+}
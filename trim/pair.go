@@ -0,0 +1,75 @@
+package trim
+
+// Handles paired-end reads.
+
+import (
+	"github.com/fluhus/golgi/formats/fastq"
+	"github.com/fluhus/golgi/sequtil"
+)
+
+// PairOverlap detects adapter read-through in paired-end reads. When the
+// insert is shorter than the reads, each mate reads into the adapter after
+// the insert, and the start of one mate overlaps the reverse complement of
+// the start of the other.
+type PairOverlap struct {
+	MinOverlap int // Shorter inserts are not detected
+	Tolerance  int // n bases may have n/Tolerance mismatches; none if <= 0
+}
+
+// Insert returns the insert length of a pair if it is shorter than either
+// mate, or -1 if no read-through is detected. Takes the longest matching
+// overlap. Case insensitive.
+func (p *PairOverlap) Insert(seq1, seq2 []byte) int {
+	for n := min(len(seq1), len(seq2)) - 1; n >= max(p.MinOverlap, 1); n-- {
+		if matchesRC(seq1[:n], seq2[:n], mismatches(n, p.Tolerance)) {
+			return n
+		}
+	}
+	return -1
+}
+
+// Returns the number of mismatches allowed in n bases: n/tolerance, or 0 if
+// tolerance is not positive.
+func mismatches(n, tolerance int) int {
+	if tolerance <= 0 {
+		return 0
+	}
+	return n / tolerance
+}
+
+// Returns whether a and the reverse complement of b have at most k
+// mismatches. Assumes equal lengths.
+func matchesRC(a, b []byte, k int) bool {
+	for i := range a {
+		c, ok := sequtil.ComplementIUPAC(b[len(b)-1-i])
+		if !ok || upper(a[i]) != upper(c) {
+			k--
+			if k < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// TrimPair trims both mates of a paired-end read in place, and returns
+// whether each should be kept. If Overlap is set, read-through is trimmed
+// from both mates before the other steps.
+func (t *Trimmer) TrimPair(fq1, fq2 *fastq.Fastq) (keep1, keep2 bool) {
+	if fq1 == nil || fq2 == nil {
+		panic("unexpected nil fastq")
+	}
+	readThrough := 0 // Bases trimmed.
+	if t.Overlap != nil {
+		if n := t.Overlap.Insert(fq1.Sequence, fq2.Sequence); n != -1 {
+			readThrough = len(fq1.Sequence) + len(fq2.Sequence) - 2*n
+			fq1.Sequence, fq1.Quals = fq1.Sequence[:n], fq1.Quals[:n]
+			fq2.Sequence, fq2.Quals = fq2.Sequence[:n], fq2.Quals[:n]
+		}
+	}
+	keep1, keep2 = t.Trim(fq1), t.Trim(fq2)
+	if t.Stats != nil {
+		t.Stats.addPair(readThrough, keep1, keep2)
+	}
+	return keep1, keep2
+}
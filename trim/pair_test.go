@@ -0,0 +1,95 @@
+package trim
+
+import (
+	"testing"
+
+	"github.com/fluhus/golgi/formats/fastq"
+	"github.com/fluhus/golgi/sequtil"
+)
+
+// Returns a pair of reads of the given insert with adapter read-through.
+func readThrough(insert, adapter1, adapter2 string) (string, string) {
+	return insert + adapter1,
+		sequtil.ReverseComplementString(insert) + adapter2
+}
+
+func TestPairOverlap(t *testing.T) {
+	p := &PairOverlap{MinOverlap: 5, Tolerance: 10}
+	r1, r2 := readThrough("ACGTTGCAAGGCTTAC", "AGATCGGAAG", "AGATCGGTTC")
+	tests := []struct {
+		seq1, seq2 string
+		want       int
+	}{
+		{r1, r2, 16},
+		{r1[:20], r2, 16},
+		{r1, "TTTTTTTTTTTTTTTTTTTTTTTTT", -1},
+		{r1[:16], r2[:16], -1},
+		{"ACGTTAGATCGG", "AACGTAGATCGG", 5},
+		{"ACGTTAGATCGG", "AACGAAGATCGG", -1},
+	}
+	for _, test := range tests {
+		got := p.Insert([]byte(test.seq1), []byte(test.seq2))
+		if got != test.want {
+			t.Errorf("Insert(%q,%q)=%v, want %v",
+				test.seq1, test.seq2, got, test.want)
+		}
+	}
+}
+
+func TestPairOverlap_zero(t *testing.T) {
+	p := &PairOverlap{} // No mismatches.
+	r1, r2 := readThrough("ACGTTGCAAGGCTTAC", "AGATCGGAAG", "AGATCGGTTC")
+	if got := p.Insert([]byte(r1), []byte(r2)); got != 16 {
+		t.Errorf("Insert(%q,%q)=%v, want 16", r1, r2, got)
+	}
+	r2 = "A" + r2[1:] // One mismatch.
+	if got := p.Insert([]byte(r1), []byte(r2)); got != -1 {
+		t.Errorf("Insert(%q,%q)=%v, want -1", r1, r2, got)
+	}
+}
+
+func TestTrimPair(t *testing.T) {
+	tr := &Trimmer{
+		Overlap:   &PairOverlap{MinOverlap: 5, Tolerance: 10},
+		MinLength: 10,
+		Stats:     &Stats{},
+	}
+	r1, r2 := readThrough("ACGTTGCAAGGCTTAC", "AGATCGGAAG", "AGATCGGTTC")
+	fq1 := &fastq.Fastq{Sequence: []byte(r1),
+		Quals: []byte("IIIIIIIIIIIIIIIIIIIIIIIIII")}
+	fq2 := &fastq.Fastq{Sequence: []byte(r2),
+		Quals: []byte("IIIIIIIIIIIIIIIIIIIIIIIIII")}
+	keep1, keep2 := tr.TrimPair(fq1, fq2)
+	if !keep1 || !keep2 {
+		t.Fatalf("TrimPair()=%v,%v, want true,true", keep1, keep2)
+	}
+	if got, want := string(fq1.Sequence), "ACGTTGCAAGGCTTAC"; got != want {
+		t.Errorf("TrimPair() mate 1=%q, want %q", got, want)
+	}
+	if got, want := string(fq2.Sequence), "GTAAGCCTTGCAACGT"; got != want {
+		t.Errorf("TrimPair() mate 2=%q, want %q", got, want)
+	}
+	if len(fq1.Quals) != 16 || len(fq2.Quals) != 16 {
+		t.Errorf("TrimPair() quals lengths=%v,%v, want 16,16",
+			len(fq1.Quals), len(fq2.Quals))
+	}
+
+	fq1 = &fastq.Fastq{Sequence: []byte("ACGTACGTACGT"),
+		Quals: []byte("IIIIIIIIIIII")}
+	fq2 = &fastq.Fastq{Sequence: []byte("GGGG"), Quals: []byte("IIII")}
+	keep1, keep2 = tr.TrimPair(fq1, fq2)
+	if !keep1 || keep2 {
+		t.Errorf("TrimPair()=%v,%v, want true,false", keep1, keep2)
+	}
+
+	s := tr.Stats
+	if s.Pairs != 2 || s.ReadThrough != 1 || s.Orphans != 1 || s.Reads != 4 ||
+		s.Short != 1 {
+		t.Errorf("Stats=%+v, want 2 pairs, 1 read-through, 1 orphan, "+
+			"4 reads, 1 short", s)
+	}
+	if s.Bases != 68 || s.ReadThroughBases != 20 {
+		t.Errorf("Stats bases=%v,%v, want 68,20", s.Bases,
+			s.ReadThroughBases)
+	}
+}
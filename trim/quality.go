@@ -0,0 +1,43 @@
+package trim
+
+// Handles trimming of low quality ends of the read.
+
+// Quality trims low quality ends. The algorithm is like Trim Galore's:
+// subtract the threshold from all quals, then sum the quals from the
+// beginning, and trim where the sum is minimal.
+type Quality struct {
+	Threshold int // Quality threshold
+	Offset    int // Phred quality offset, usually 33
+}
+
+// Name returns "quality".
+func (q *Quality) Name() string {
+	return "quality"
+}
+
+// Trim returns the high quality part of the read.
+func (q *Quality) Trim(seq, quals []byte) (start, end int) {
+	sum := 0
+	minSum := 0
+	minPos := 0
+	maxSum := 0
+	maxPos := 0
+
+	for i, qual := range quals {
+		sum += int(qual) - q.Offset - q.Threshold
+		if sum < minSum {
+			minSum = sum
+			minPos = i + 1
+		}
+		if sum >= maxSum {
+			maxSum = sum
+			maxPos = i + 1
+		}
+	}
+
+	// Bad quality makes max go past min
+	if maxPos <= minPos {
+		return 0, 0
+	}
+	return minPos, maxPos
+}
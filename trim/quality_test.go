@@ -1,4 +1,4 @@
-package main
+package trim
 
 import (
 	"testing"
@@ -11,7 +11,7 @@ func Test_Simple(t *testing.T) {
 	fq.Sequence = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
 	fq.Quals = []byte{10, 9, 11, 8, 15, 16, 9, 10, 9}
 
-	trimQual(fq, 0, 10)
+	apply(fq, &Quality{10, 0})
 
 	newSequence := []byte{5, 6}
 	newQuals := []byte{15, 16}
@@ -28,7 +28,7 @@ func Test_BadQuals(t *testing.T) {
 	fq.Sequence = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
 	fq.Quals = []byte{7, 9, 7, 8, 1, 10, 9, 10, 9}
 
-	trimQual(fq, 0, 10)
+	apply(fq, &Quality{10, 0})
 
 	if len(fq.Sequence) > 0 {
 		t.Errorf("bad sequence: %v, expected empty", fq.Sequence)
@@ -41,7 +41,7 @@ func Test_BadQuals(t *testing.T) {
 func Test_Empty(t *testing.T) {
 	fq := &fastq.Fastq{}
 
-	trimQual(fq, 0, 10)
+	apply(fq, &Quality{10, 0})
 
 	if len(fq.Sequence) > 0 {
 		t.Errorf("bad sequence: %v, expected empty", fq.Sequence)
@@ -0,0 +1,163 @@
+// Package trim trims low quality ends and adapter contamination from
+// sequencing reads.
+//
+// A Trimmer applies a list of steps to each read, and drops reads that
+// become too short:
+//
+//	t := &trim.Trimmer{
+//		Steps: []trim.Step{
+//			&trim.Quality{Threshold: 20, Offset: 33},
+//			&trim.AdapterEnd{Adapter: []byte("AGATCGGAAGAGC"), Tolerance: 10},
+//		},
+//		MinLength: 20,
+//		Stats:     &trim.Stats{},
+//	}
+//	for fq, err := range r.All() {
+//		...
+//		if t.Trim(fq) {
+//			// Write fq.
+//		}
+//	}
+//
+// Paired-end reads are trimmed with TrimPair, which can also detect adapter
+// read-through from the overlap of the mates.
+package trim
+
+import (
+	"sync"
+
+	"github.com/fluhus/golgi/formats/fastq"
+)
+
+// Step is a trimming step. Steps must be safe for concurrent use.
+type Step interface {
+	// Name returns a short name of the step for statistics, like "quality".
+	Name() string
+
+	// Trim returns the part of the read to keep, as [start,end).
+	Trim(seq, quals []byte) (start, end int)
+}
+
+// LabeledStep is a step that also reports a label for each read it trims,
+// like the name of the adapter found. Stats count trimmed reads by label.
+type LabeledStep interface {
+	Step
+
+	// TrimLabeled returns the part of the read to keep, as [start,end), and
+	// a label of what was trimmed. The label is ignored if nothing was
+	// trimmed.
+	TrimLabeled(seq, quals []byte) (start, end int, label string)
+}
+
+// Trimmer trims reads by applying steps in order. Fields should not be
+// changed while trimming. A Trimmer is safe for concurrent use.
+type Trimmer struct {
+	Steps     []Step       // Applied in order
+	MinLength int          // Reads that become shorter are dropped
+	Stats     *Stats       // Collects statistics; may be nil
+	Overlap   *PairOverlap // Detects read-through in pairs; may be nil
+}
+
+// Trim trims the read in place, and returns whether it should be kept.
+func (t *Trimmer) Trim(fq *fastq.Fastq) bool {
+	if fq == nil {
+		panic("unexpected nil fastq")
+	}
+	var trimmed []int
+	var labels []string
+	if t.Stats != nil {
+		trimmed = make([]int, len(t.Steps))
+		labels = make([]string, len(t.Steps))
+	}
+	lenBefore := len(fq.Sequence)
+	for i, step := range t.Steps {
+		var start, end int
+		var label string
+		if ls, ok := step.(LabeledStep); ok {
+			start, end, label = ls.TrimLabeled(fq.Sequence, fq.Quals)
+		} else {
+			start, end = step.Trim(fq.Sequence, fq.Quals)
+		}
+		if trimmed != nil {
+			trimmed[i] = len(fq.Sequence) - (end - start)
+			labels[i] = label
+		}
+		fq.Sequence = fq.Sequence[start:end]
+		fq.Quals = fq.Quals[start:end]
+	}
+	keep := len(fq.Sequence) >= t.MinLength
+	if t.Stats != nil {
+		t.Stats.add(t.Steps, lenBefore, trimmed, labels, keep)
+	}
+	return keep
+}
+
+// Stats collects trimming statistics. It is safe for concurrent use.
+// Fields should be read only after trimming is done.
+type Stats struct {
+	Reads int         // Number of reads processed
+	Bases int         // Number of bases in processed reads
+	Short int         // Number of reads dropped for being too short
+	Steps []StepStats // By order of steps
+
+	Pairs            int // Number of pairs processed
+	ReadThrough      int // Number of pairs trimmed for read-through
+	ReadThroughBases int // Number of bases trimmed for read-through
+	Orphans          int // Number of pairs where only one mate was kept
+
+	mu sync.Mutex
+}
+
+// StepStats holds the statistics of a single step.
+type StepStats struct {
+	Name    string         // Name of the step
+	Bases   int            // Number of bases trimmed
+	Lengths []int          // Number of reads by number of bases trimmed
+	Labels  map[string]int // Number of trimmed reads by label
+}
+
+// Adds the results of a pair, where the given number of bases were trimmed
+// for read-through. The mates are added separately, after read-through
+// trimming.
+func (s *Stats) addPair(readThrough int, keep1, keep2 bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Pairs++
+	if readThrough > 0 {
+		s.ReadThrough++
+		s.ReadThroughBases += readThrough
+		s.Bases += readThrough
+	}
+	if keep1 != keep2 {
+		s.Orphans++
+	}
+}
+
+// Adds the results of a single read.
+func (s *Stats) add(steps []Step, bases int, trimmed []int, labels []string,
+	keep bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Reads++
+	s.Bases += bases
+	if !keep {
+		s.Short++
+	}
+	for len(s.Steps) < len(steps) {
+		s.Steps = append(s.Steps, StepStats{Name: steps[len(s.Steps)].Name()})
+	}
+	for i, n := range trimmed {
+		st := &s.Steps[i]
+		st.Bases += n
+		for len(st.Lengths) <= n {
+			st.Lengths = append(st.Lengths, 0)
+		}
+		st.Lengths[n]++
+		if n > 0 && labels[i] != "" {
+			if st.Labels == nil {
+				st.Labels = map[string]int{}
+			}
+			st.Labels[labels[i]]++
+		}
+	}
+}
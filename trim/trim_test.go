@@ -0,0 +1,84 @@
+package trim
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/fluhus/golgi/formats/fastq"
+)
+
+// Applies a single step to a read.
+func apply(fq *fastq.Fastq, s Step) {
+	start, end := s.Trim(fq.Sequence, fq.Quals)
+	fq.Sequence = fq.Sequence[start:end]
+	fq.Quals = fq.Quals[start:end]
+}
+
+func TestTrimmer(t *testing.T) {
+	tr := &Trimmer{
+		Steps: []Step{
+			&Quality{Threshold: 20, Offset: 33},
+			&AdapterStart{Adapter: []byte("AACCGTCTCA"), Tolerance: 10},
+			&AdapterEnd{Adapter: []byte("GGTTATGAC"), Tolerance: 10},
+		},
+		MinLength: 5,
+		Stats:     &Stats{},
+	}
+	fq := &fastq.Fastq{Sequence: []byte("TCTCATCTGGTTGGTTA"),
+		Quals: []byte("**IIIIIIIIIIII***")}
+	if !tr.Trim(fq) {
+		t.Fatalf("Trim(%q)=false, want true", fq.Sequence)
+	}
+	if got, want := string(fq.Sequence), "TCTGGTT"; got != want {
+		t.Errorf("Trim()=%q, want %q", got, want)
+	}
+	if got, want := string(fq.Quals), "IIIIIII"; got != want {
+		t.Errorf("Trim() quals=%q, want %q", got, want)
+	}
+
+	fq = &fastq.Fastq{Sequence: []byte("ACGTACGT"),
+		Quals: []byte("********")}
+	if tr.Trim(fq) {
+		t.Errorf("Trim(%q)=true, want false", fq.Sequence)
+	}
+
+	want := &Stats{Reads: 2, Bases: 25, Short: 1, Steps: []StepStats{
+		{"quality", 13, []int{0, 0, 0, 0, 0, 1, 0, 0, 1}, nil},
+		{"adapter-start", 3, []int{1, 0, 0, 1}, nil},
+		{"adapter-end", 2, []int{1, 0, 1}, nil},
+	}}
+	if !reflect.DeepEqual(tr.Stats, want) {
+		t.Errorf("Stats=%v, want %v", tr.Stats, want)
+	}
+}
+
+func TestTrimmer_concurrent(t *testing.T) {
+	tr := &Trimmer{
+		Steps: []Step{
+			&AdapterStart{Adapter: []byte("GGCACTA"), Tolerance: 5},
+		},
+		MinLength: 1,
+		Stats:     &Stats{},
+	}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				fq := &fastq.Fastq{Sequence: []byte("ACTAGGTTCA"),
+					Quals: []byte("IIIIIIIIII")}
+				tr.Trim(fq)
+				if string(fq.Sequence) != "GGTTCA" {
+					t.Errorf("Trim()=%q, want GGTTCA", fq.Sequence)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if tr.Stats.Reads != 800 || tr.Stats.Steps[0].Bases != 3200 {
+		t.Errorf("Stats=%v, want 800 reads and 3200 bases", tr.Stats)
+	}
+}
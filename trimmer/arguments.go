@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 	"os"
 	"runtime/pprof"
+
+	"github.com/fluhus/golgi/trim"
 )
 
 // Parsed arguments.
@@ -18,14 +20,27 @@ var (
 	outputFile    *os.File      // output file
 	inputReader   *bufio.Reader // read from here
 	outputWriter  *bufio.Writer // write to here
+	paired        bool          // are reads paired-end?
+	inputFile2    *os.File      // input file of second mates
+	outputFile2   *os.File      // output file of second mates
+	singleFile    *os.File      // output file of orphaned mates, may be nil
+	inputReader2  *bufio.Reader // read second mates from here
+	outputWriter2 *bufio.Writer // write second mates to here
+	singleWriter  *bufio.Writer // write orphaned mates to here, may be nil
+	minOverlap    int           // minimal insert for read-through detection
+	tolerance     int           // allowed mismatches are overlap/tolerance
+	errorRate     float64       // if positive, adapters may have indels
+	adapterMin    int           // minimal partial adapter overlap
 	profileFile   *os.File      // profiling information is written to here
-	adapterStart  []byte        // adapter to trim from start
-	adapterEnd    []byte        // adapter to trim from end
+	adapterStart  adapterList   // adapters to trim from start
+	adapterEnd    adapterList   // adapters to trim from end
+	autoDetect    bool          // detect the end adapter from the first reads
 	phredOffset   int           // phred quality offset
 	qualThreshold int           // quality trimming threshold
 	minReadLength int           // Shorter reads are omitted
 	printHelp     bool          // should I print help message?
 	argumentError error         // not nil if an error occured
+	trimmer       *trim.Trimmer // trims reads according to the arguments
 )
 
 // Parses command line arguments.
@@ -40,15 +55,35 @@ func parseArguments() {
 	output := flags.String("out", "", "")
 	flags.StringVar(output, "o", "", "")
 
+	input1 := flags.String("in1", "", "")
+	input2 := flags.String("in2", "", "")
+	output1 := flags.String("out1", "", "")
+	output2 := flags.String("out2", "", "")
+	singles := flags.String("singletons", "", "")
+	flags.StringVar(singles, "s", "", "")
+
+	flags.IntVar(&minOverlap, "min-overlap", 20, "")
+
 	profile := flags.String("profile", "", "")
 
-	var adapterStartString string
-	flags.StringVar(&adapterStartString, "adapter-start", "", "")
-	flags.StringVar(&adapterStartString, "as", "", "")
+	adapterStart, adapterEnd, autoDetect = nil, nil, false
+	flags.Var(&adapterStart, "adapter-start", "")
+	flags.Var(&adapterStart, "as", "")
+	flags.Var(&adapterEnd, "adapter-end", "")
+	flags.Var(&adapterEnd, "ae", "")
+
+	adapterFileStart := flags.String("adapter-file-start", "", "")
+	flags.StringVar(adapterFileStart, "afs", "", "")
+	adapterFileEnd := flags.String("adapter-file-end", "", "")
+	flags.StringVar(adapterFileEnd, "afe", "", "")
+
+	flags.IntVar(&tolerance, "tolerance", 10, "")
+	flags.IntVar(&tolerance, "t", 10, "")
+
+	flags.Float64Var(&errorRate, "error-rate", 0, "")
+	flags.Float64Var(&errorRate, "e", 0, "")
 
-	var adapterEndString string
-	flags.StringVar(&adapterEndString, "adapter-end", "", "")
-	flags.StringVar(&adapterEndString, "ae", "", "")
+	flags.IntVar(&adapterMin, "adapter-overlap", 1, "")
 
 	flags.IntVar(&phredOffset, "phred-offset", 33, "")
 	flags.IntVar(&phredOffset, "p", 33, "")
@@ -83,43 +118,46 @@ func parseArguments() {
 		return
 	}
 
-	// Convert adapter strings to byte slices
-	adapterStart = []byte(adapterStartString)
-	adapterEnd = []byte(adapterEndString)
-
-	// Initialize statistic slices
-	adapterStartCount = make([]int, len(adapterStart)+1)
-	adapterEndCount = make([]int, len(adapterEnd)+1)
-
-	// Open i/o files
-	if *input == "" {
-		argumentError = errors.New("No input file given.")
+	if tolerance < 1 {
+		argumentError = fmt.Errorf("Bad tolerance: %d", tolerance)
 		return
 	}
-
-	if *output == "" {
-		argumentError = errors.New("No output file given.")
+	if errorRate < 0 || errorRate >= 1 {
+		argumentError = fmt.Errorf("Bad error rate: %v", errorRate)
 		return
 	}
 
-	if *input == "stdin" {
-		inputFile = os.Stdin
-	} else {
-		inputFile, argumentError = os.Open(*input)
-		if argumentError != nil {
-			return
-		}
+	// Load adapter files
+	if argumentError = adapterStart.load(*adapterFileStart); argumentError !=
+		nil {
+		return
+	}
+	if argumentError = adapterEnd.load(*adapterFileEnd); argumentError !=
+		nil {
+		return
+	}
+	autoDetect = adapterEnd.removeAuto()
+	if adapterStart.removeAuto() {
+		argumentError = errors.New("Adapter detection is only supported " +
+			"for the end adapter.")
+		return
 	}
 
-	if *output == "stdout" {
-		outputFile = os.Stdout
+	// Open i/o files
+	paired = *input1 != "" || *input2 != "" || *output1 != "" ||
+		*output2 != ""
+	if paired {
+		argumentError = openPairedFiles(*input, *output, *input1, *input2,
+			*output1, *output2, *singles)
 	} else {
-		outputFile, argumentError = os.Create(*output)
-		if argumentError != nil {
-			return
-		}
+		argumentError = openFiles(*input, *output, *singles)
+	}
+	if argumentError != nil {
+		return
 	}
 
+	trimmer = newTrimmer()
+
 	if *profile != "" {
 		profileFile, argumentError = os.Create(*profile)
 		if argumentError != nil {
@@ -134,6 +172,183 @@ func parseArguments() {
 	// Create buffered i/o
 	inputReader = bufio.NewReader(inputFile)
 	outputWriter = bufio.NewWriter(outputFile)
+	singleWriter = nil
+	if paired {
+		inputReader2 = bufio.NewReader(inputFile2)
+		outputWriter2 = bufio.NewWriter(outputFile2)
+		if singleFile != nil {
+			singleWriter = bufio.NewWriter(singleFile)
+		}
+	}
+}
+
+// Opens the files of single-end mode.
+func openFiles(input, output, singles string) error {
+	if input == "" {
+		return errors.New("No input file given.")
+	}
+	if output == "" {
+		return errors.New("No output file given.")
+	}
+	if singles != "" {
+		return errors.New("Singletons file is only used in paired mode.")
+	}
+
+	var err error
+	inputFile, err = openInput(input)
+	if err != nil {
+		return err
+	}
+	outputFile, err = createOutput(output)
+	return err
+}
+
+// Opens the files of paired-end mode.
+func openPairedFiles(input, output, input1, input2, output1, output2,
+	singles string) error {
+	if input != "" || output != "" {
+		return errors.New("Cannot use -in and -out in paired mode.")
+	}
+	if input1 == "" || input2 == "" {
+		return errors.New("Paired mode needs both -in1 and -in2.")
+	}
+	if output1 == "" || output2 == "" {
+		return errors.New("Paired mode needs both -out1 and -out2.")
+	}
+
+	var err error
+	if inputFile, err = openInput(input1); err != nil {
+		return err
+	}
+	if inputFile2, err = openInput(input2); err != nil {
+		return err
+	}
+	if outputFile, err = createOutput(output1); err != nil {
+		return err
+	}
+	if outputFile2, err = createOutput(output2); err != nil {
+		return err
+	}
+	if singles != "" {
+		if singleFile, err = createOutput(singles); err != nil {
+			return err
+		}
+	} else {
+		singleFile = nil
+	}
+	return nil
+}
+
+// Opens an input file. 'stdin' stands for standard input.
+func openInput(path string) (*os.File, error) {
+	if path == "stdin" {
+		return os.Stdin, nil
+	}
+	return os.Open(path)
+}
+
+// Creates an output file. 'stdout' stands for standard output.
+func createOutput(path string) (*os.File, error) {
+	if path == "stdout" {
+		return os.Stdout, nil
+	}
+	return os.Create(path)
+}
+
+// Returns a trimmer with the steps selected by the arguments.
+func newTrimmer() *trim.Trimmer {
+	t := &trim.Trimmer{MinLength: minReadLength, Stats: &trim.Stats{}}
+	if qualThreshold != 0 {
+		t.Steps = append(t.Steps,
+			&trim.Quality{Threshold: qualThreshold, Offset: phredOffset})
+	}
+	if len(adapterStart) > 0 {
+		t.Steps = append(t.Steps, &trim.Adapters{Adapters: adapterStart,
+			Start: true, Tolerance: tolerance, MinOverlap: adapterMin,
+			ErrorRate: errorRate})
+	}
+	if len(adapterEnd) > 0 {
+		t.Steps = append(t.Steps, &trim.Adapters{Adapters: adapterEnd,
+			Tolerance: tolerance, MinOverlap: adapterMin,
+			ErrorRate: errorRate})
+	}
+	if paired && minOverlap > 0 {
+		t.Overlap = &trim.PairOverlap{MinOverlap: minOverlap,
+			Tolerance: tolerance}
+	}
+	return t
+}
+
+// Adapters given on the command line. Implements flag.Value.
+type adapterList []trim.Adapter
+
+func (a *adapterList) String() string {
+	if a == nil {
+		return ""
+	}
+	return fmt.Sprint(*a)
+}
+
+// Adds an adapter, named by its sequence.
+func (a *adapterList) Set(s string) error {
+	if s == "" {
+		return errors.New("empty adapter")
+	}
+	return a.add(trim.Adapter{Name: s, Sequence: []byte(s)})
+}
+
+// Adds an adapter. Names should be unique, since hits are counted by name.
+func (a *adapterList) add(ad trim.Adapter) error {
+	if a.has(ad.Name) {
+		return fmt.Errorf("duplicate adapter: %q", ad.Name)
+	}
+	*a = append(*a, ad)
+	return nil
+}
+
+// Returns whether the list has an adapter with the given name.
+func (a adapterList) has(name string) bool {
+	for _, ad := range a {
+		if ad.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Adds the adapters of a fasta file, if a file is given.
+func (a *adapterList) load(file string) error {
+	if file == "" {
+		return nil
+	}
+	adapters, err := trim.LoadAdapters(file)
+	if err != nil {
+		return err
+	}
+	if len(adapters) == 0 {
+		return fmt.Errorf("No adapters in %s.", file)
+	}
+	for _, ad := range adapters {
+		if err := a.add(ad); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	return nil
+}
+
+// Removes 'auto' from the list. Returns true if it was there.
+func (a *adapterList) removeAuto() bool {
+	found := false
+	result := (*a)[:0]
+	for _, ad := range *a {
+		if ad.Name == "auto" {
+			found = true
+		} else {
+			result = append(result, ad)
+		}
+	}
+	*a = result
+	return found
 }
 
 // Printed if arguments are bad.
@@ -143,6 +358,8 @@ Written by Amit Lavon (amitlavon1@gmail.com).
 
 Usage:
 trimmer [options] -in <input file> -out <output file>
+trimmer [options] -in1 <input 1> -in2 <input 2>
+	-out1 <output 1> -out2 <output 2>
 
 Options:
 	-h
@@ -157,6 +374,28 @@ Options:
 	-out <path>
 		Output fastq file. Give 'stdout' for standard output.
 
+	-in1 <path>
+	-in2 <path>
+		Input fastq files of first and second mates, for paired-end reads.
+		Mates should be in the same order in both files.
+
+	-out1 <path>
+	-out2 <path>
+		Output fastq files of first and second mates, for paired-end reads.
+
+	-s <path>
+	-singletons <path>
+		Output fastq file for mates whose pair was omitted, in paired mode.
+		Default: none (such mates are omitted too).
+
+	-min-overlap <integer>
+		In paired mode, pairs whose mates overlap by at least this many
+		bases, such that the insert is shorter than the reads, are trimmed
+		to the insert. Give 0 to avoid read-through detection. Short
+		overlaps match by chance: with tolerance 10, about 1 in 25,000
+		pairs is falsely trimmed at 10, and practically none at 20.
+		Default: 20.
+
 	-q <integer>
 	-qual-threshold <integer>
 		Quality trimmming threshold. Give 0 to avoid quality trimming.
@@ -168,11 +407,46 @@ Options:
 
 	-as <string>
 	-adapter-start <string>
-		Adapter to trim at the beginning (5') of the read. Default: none.
+		Adapter to trim at the beginning (5') of the read. May be given
+		several times, in which case the adapter that trims the most bases
+		is used for each read. Default: none.
 
 	-ae <string>
 	-adapter-end <string>
-		Adapter to trim at the end (3') of the read. Default: none.
+		Adapter to trim at the end (3') of the read. May be given several
+		times, like -as. Give 'auto' to detect Illumina TruSeq, Nextera or
+		small RNA adapters by their frequency in the first 100,000
+		reads. Default: none.
+
+	-afs <path>
+	-adapter-file-start <path>
+		Fasta file of adapters to trim at the beginning (5') of the read,
+		in addition to -as. Default: none.
+
+	-afe <path>
+	-adapter-file-end <path>
+		Fasta file of adapters to trim at the end (3') of the read, in
+		addition to -ae. Default: none.
+
+	Adapters are reported by name, which is the sequence for -as and -ae,
+	so names must be unique on each end.
+
+	-t <integer>
+	-tolerance <integer>
+		An adapter overlap of n bases may have n/tolerance mismatches.
+		Also used for read-through detection in paired mode. Default: 10.
+
+	-e <number>
+	-error-rate <number>
+		If positive, adapters are searched anywhere in the read, allowing
+		mismatches and indels. An adapter match of n bases may have
+		n*error-rate errors. Tolerance is then ignored for adapters.
+		Default: 0 (exact whole adapters anywhere in the read, or adapters
+		that overlap the read's end, with no indels).
+
+	-adapter-overlap <integer>
+		Adapter overlaps with the read's end that are shorter than this are
+		ignored. Default: 1.
 
 	-l <integer>
 	-min-length <integer>
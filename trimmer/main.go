@@ -1,12 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
 	"runtime/pprof"
 
 	"github.com/fluhus/golgi/formats/fastq"
+	"github.com/fluhus/golgi/trim"
 )
 
 func main() {
@@ -27,7 +29,11 @@ func main() {
 
 	// Get to work!
 	printWorkPlan()
-	processReads()
+	if paired {
+		processPairs()
+	} else {
+		processReads()
+	}
 	flushAndCloseFiles()
 	printStatistics()
 
@@ -39,16 +45,26 @@ func printWorkPlan() {
 	fmt.Fprintln(os.Stderr, "Biostuff Trimmer - Workplan")
 	fmt.Fprintln(os.Stderr, "~~~~~~~~~~~~~~~~~~~~~~~~~~~")
 
-	if inputFile == os.Stdin {
-		fmt.Fprintln(os.Stderr, "Input:  stdin")
+	if paired {
+		fmt.Fprintln(os.Stderr, "Input:  ", inputFile.Name(),
+			inputFile2.Name())
+		fmt.Fprintln(os.Stderr, "Output: ", outputFile.Name(),
+			outputFile2.Name())
+		if singleFile != nil {
+			fmt.Fprintln(os.Stderr, "Singletons:", singleFile.Name())
+		}
 	} else {
-		fmt.Fprintln(os.Stderr, "Input: ", inputFile.Name())
-	}
+		if inputFile == os.Stdin {
+			fmt.Fprintln(os.Stderr, "Input:  stdin")
+		} else {
+			fmt.Fprintln(os.Stderr, "Input: ", inputFile.Name())
+		}
 
-	if outputFile == os.Stdout {
-		fmt.Fprintln(os.Stderr, "Output: stdout")
-	} else {
-		fmt.Fprintln(os.Stderr, "Output:", outputFile.Name())
+		if outputFile == os.Stdout {
+			fmt.Fprintln(os.Stderr, "Output: stdout")
+		} else {
+			fmt.Fprintln(os.Stderr, "Output:", outputFile.Name())
+		}
 	}
 
 	if profileFile != nil {
@@ -62,14 +78,31 @@ func printWorkPlan() {
 			qualThreshold, phredOffset)
 	}
 
-	if len(adapterStart) > 0 {
-		fmt.Fprintln(os.Stderr, "\tTrim adapter from start:",
-			string(adapterStart))
+	for _, a := range adapterStart {
+		fmt.Fprintln(os.Stderr, "\tTrim adapter from start:", a.Name)
+	}
+
+	for _, a := range adapterEnd {
+		fmt.Fprintln(os.Stderr, "\tTrim adapter from end:", a.Name)
+	}
+
+	if autoDetect {
+		fmt.Fprintln(os.Stderr, "\tTrim adapter from end: auto-detect")
+	}
+
+	if len(adapterStart) > 0 || len(adapterEnd) > 0 || autoDetect {
+		if errorRate > 0 {
+			fmt.Fprintf(os.Stderr, "\tAdapter error rate: %v; "+
+				"min overlap: %d\n", errorRate, adapterMin)
+		} else {
+			fmt.Fprintf(os.Stderr, "\tAdapter tolerance: %d; "+
+				"min overlap: %d\n", tolerance, adapterMin)
+		}
 	}
 
-	if len(adapterEnd) > 0 {
-		fmt.Fprintln(os.Stderr, "\tTrim adapter from end:",
-			string(adapterEnd))
+	if trimmer.Overlap != nil {
+		fmt.Fprintln(os.Stderr, "\tTrim adapter read-through; min overlap:",
+			minOverlap)
 	}
 
 	fmt.Fprintln(os.Stderr, "\tOmit reads shorter than:",
@@ -83,57 +116,158 @@ func processReads() {
 	// Read fastq
 	var err error
 	var fq *fastq.Fastq
-	r := fastq.NewReader(inputReader)
+	next := fastq.NewReader(inputReader).Next
+	if autoDetect {
+		next = detectAdapter(next)
+	}
+
+	for fq, err = next(); err == nil; fq, err = next() {
+		// Trim, and print if long enough
+		if trimmer.Trim(fq) {
+			writeFastq(outputWriter, fq)
+		}
+	}
 
-	for fq, err = r.Next(); err == nil; fq, err = r.Next() {
-		readCount++
-		nucleotideCount += len(fq.Sequence)
+	if err != io.EOF {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "Output file contents are invalid.")
+		os.Exit(1)
+	}
+}
 
-		if qualThreshold != 0 {
-			lenBefore := len(fq.Sequence)
-			trimQual(fq, phredOffset, qualThreshold)
-			lenAfter := len(fq.Sequence)
+// Does the read processing of paired-end reads, exits on error.
+func processPairs() {
+	next1 := fastq.NewReader(inputReader).Next
+	next2 := fastq.NewReader(inputReader2).Next
+	if autoDetect {
+		next1, next2 = detectAdapterPairs(next1, next2)
+	}
 
-			qualCount += lenBefore - lenAfter
+	for {
+		fq1, err1 := next1()
+		fq2, err2 := next2()
+		if err1 == io.EOF && err2 == io.EOF {
+			break
+		}
+		if err1 == io.EOF || err2 == io.EOF {
+			fmt.Fprintln(os.Stderr, "Input files have different numbers "+
+				"of reads.")
+			fmt.Fprintln(os.Stderr, "Output file contents are invalid.")
+			os.Exit(1)
+		}
+		for _, err := range []error{err1, err2} {
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, "Output file contents are invalid.")
+				os.Exit(1)
+			}
 		}
 
-		if len(adapterStart) > 0 {
-			lenBefore := len(fq.Sequence)
-			trimAdapterStart(fq, adapterStart, 10) // 10 is arbitrary for now
-			lenAfter := len(fq.Sequence)
-
-			adapterStartCount[lenBefore-lenAfter]++
+		// Write pairs, and orphans if requested
+		keep1, keep2 := trimmer.TrimPair(fq1, fq2)
+		switch {
+		case keep1 && keep2:
+			writeFastq(outputWriter, fq1)
+			writeFastq(outputWriter2, fq2)
+		case keep1 && singleWriter != nil:
+			writeFastq(singleWriter, fq1)
+		case keep2 && singleWriter != nil:
+			writeFastq(singleWriter, fq2)
 		}
+	}
+}
 
-		if len(adapterEnd) > 0 {
-			lenBefore := len(fq.Sequence)
-			trimAdapterEnd(fq, adapterEnd, 10) // 10 is arbitrary for now
-			lenAfter := len(fq.Sequence)
+// Number of first reads that are used for adapter detection.
+const detectReads = 100000
 
-			adapterEndCount[lenBefore-lenAfter]++
-		}
+// Returns the next read of an input, or an error.
+type nextFunc func() (*fastq.Fastq, error)
 
-		// Print if long enough
-		// TODO: add as command line option
-		if len(fq.Sequence) >= minReadLength {
-			// panic("Trimmer is broken. Need to fix output format below.")
-			outputWriter.WriteString("@")
-			outputWriter.Write(fq.Name)
-			outputWriter.WriteString("\n")
-			outputWriter.Write(fq.Sequence)
-			outputWriter.WriteString("\n+\n")
-			outputWriter.Write(fq.Quals)
-			outputWriter.WriteString("\n")
-		} else {
-			shortCount++
+// Reads up to detectReads reads, detects the end adapter from them and adds
+// it to the trimmer. Returns a function that returns the buffered reads and
+// then the rest of the input.
+func detectAdapter(next nextFunc) nextFunc {
+	buf, next := sampleReads(next)
+	addDetectedAdapter(sequences(buf))
+	return next
+}
+
+// Like detectAdapter, with reads from both mates.
+func detectAdapterPairs(next1, next2 nextFunc) (nextFunc, nextFunc) {
+	buf1, next1 := sampleReads(next1)
+	buf2, next2 := sampleReads(next2)
+	addDetectedAdapter(append(sequences(buf1), sequences(buf2)...))
+	return next1, next2
+}
+
+// Reads up to detectReads reads. Returns the reads and a function that
+// returns them and then the rest of the input.
+func sampleReads(next nextFunc) ([]*fastq.Fastq, nextFunc) {
+	var buf []*fastq.Fastq
+	var err error
+	for len(buf) < detectReads {
+		var fq *fastq.Fastq
+		if fq, err = next(); err != nil {
+			break
 		}
+		buf = append(buf, fq)
 	}
+	i := 0
+	return buf, func() (*fastq.Fastq, error) {
+		if i < len(buf) {
+			i++
+			return buf[i-1], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return next()
+	}
+}
 
-	if err != io.EOF {
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, "Output file contents are invalid.")
-		os.Exit(1)
+// Returns the sequences of the given reads.
+func sequences(fqs []*fastq.Fastq) [][]byte {
+	result := make([][]byte, len(fqs))
+	for i, fq := range fqs {
+		result[i] = fq.Sequence
 	}
+	return result
+}
+
+// Detects the most frequent known adapter, reports it and adds it to the
+// trimmer.
+func addDetectedAdapter(seqs [][]byte) {
+	fmt.Fprintln(os.Stderr, "Adapter detection in", len(seqs), "reads:")
+	counts := trim.CountAdapters(seqs, trim.KnownAdapters)
+	for i, a := range trim.KnownAdapters {
+		fmt.Fprintf(os.Stderr, "\t%s (%s): %d\n", a.Name, a.Sequence,
+			counts[i])
+	}
+
+	i := trim.DetectAdapter(seqs, trim.KnownAdapters)
+	if i == -1 {
+		fmt.Fprintln(os.Stderr, "No known adapter detected.")
+		fmt.Fprintln(os.Stderr)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Detected adapter:", trim.KnownAdapters[i].Name)
+	fmt.Fprintln(os.Stderr)
+	if adapterEnd.has(trim.KnownAdapters[i].Name) {
+		return // Already given.
+	}
+	adapterEnd = append(adapterEnd, trim.KnownAdapters[i])
+	trimmer = newTrimmer()
+}
+
+// Writes a fastq entry.
+func writeFastq(w *bufio.Writer, fq *fastq.Fastq) {
+	w.WriteString("@")
+	w.Write(fq.Name)
+	w.WriteString("\n")
+	w.Write(fq.Sequence)
+	w.WriteString("\n+\n")
+	w.Write(fq.Quals)
+	w.WriteString("\n")
 }
 
 // Flushes and closes i/o files.
@@ -148,6 +282,22 @@ func flushAndCloseFiles() {
 		outputFile.Close()
 	}
 
+	if paired {
+		if inputFile2 != os.Stdin {
+			inputFile2.Close()
+		}
+		outputWriter2.Flush()
+		if outputFile2 != os.Stdout {
+			outputFile2.Close()
+		}
+		if singleWriter != nil {
+			singleWriter.Flush()
+			if singleFile != os.Stdout {
+				singleFile.Close()
+			}
+		}
+	}
+
 	if profileFile != nil {
 		pprof.StopCPUProfile()
 		profileFile.Close()
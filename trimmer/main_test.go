@@ -43,7 +43,7 @@ func Test_Main(t *testing.T) {
 		}
 
 		if string(outputText) != testCases[i].output {
-			t.Log("adapterEnd:", string(adapterEnd))
+			t.Log("adapterEnd:", adapterEnd)
 			t.Fatalf("Bad output in test #%d. Expected:\n%s\nActual:\n%s",
 				i+1, testCases[i].output, string(outputText))
 		}
@@ -123,3 +123,174 @@ IIIIIIIII***
 `,
 	},
 }
+
+func Test_MainPaired(t *testing.T) {
+	// Create input files
+	in1 := writeTempFile(t, `@pair1
+ACGTTGCAAGGCTTACAGATCGGAAG
++
+IIIIIIIIIIIIIIIIIIIIIIIIII
+@pair2
+TCTCATCTGGTTGGTTAGGC
++
+IIIIIIIIIIIIIIIIIIII
+`)
+	in2 := writeTempFile(t, `@pair1
+GTAAGCCTTGCAACGTAGATCGGTTC
++
+IIIIIIIIIIIIIIIIIIIIIIIIII
+@pair2
+TTGCATGCAAGTCGTAGCTA
++
+****IIIII***********
+`)
+	out1, out2 := writeTempFile(t, ""), writeTempFile(t, "")
+	singles := writeTempFile(t, "")
+
+	// Execute program
+	os.Args = []string{"trimmer", "-l", "10", "-min-overlap", "10",
+		"-in1", in1, "-in2", in2,
+		"-out1", out1, "-out2", out2, "-s", singles}
+	main()
+
+	// Compare output
+	want := map[string]string{
+		out1: "@pair1\nACGTTGCAAGGCTTAC\n+\nIIIIIIIIIIIIIIII\n",
+		out2: "@pair1\nGTAAGCCTTGCAACGT\n+\nIIIIIIIIIIIIIIII\n",
+		singles: "@pair2\nTCTCATCTGGTTGGTTAGGC\n+\n" +
+			"IIIIIIIIIIIIIIIIIIII\n",
+	}
+	for file, text := range want {
+		got, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Could not read output file: %v", err)
+		}
+		if string(got) != text {
+			t.Errorf("Bad output in %s. Expected:\n%s\nActual:\n%s",
+				file, text, string(got))
+		}
+	}
+}
+
+// Creates a temporary file with the given content and returns its name.
+// The file is removed when the test ends.
+func writeTempFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile(".", "trimmer_test_")
+	if err != nil {
+		t.Fatalf("Could not open temporary file: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("Could not write to temporary file: %v", err)
+	}
+	return f.Name()
+}
+
+func Test_MainErrorRate(t *testing.T) {
+	in := writeTempFile(t, `@lalala
+TCTCATCTGGTTAGATCGAAGAGCTTTT
++
+IIIIIIIIIIIIIIIIIIIIIIIIIIII
+`)
+	out := writeTempFile(t, "")
+	os.Args = []string{"trimmer", "-l", "1", "-q", "0", "-e", "0.1",
+		"-ae", "AGATCGGAAGAGC", "-in", in, "-out", out}
+	main()
+
+	got, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Could not read output file: %v", err)
+	}
+	want := "@lalala\nTCTCATCTGGTT\n+\nIIIIIIIIIIII\n"
+	if string(got) != want {
+		t.Errorf("Bad output. Expected:\n%s\nActual:\n%s", want, string(got))
+	}
+}
+
+func Test_MainAdapters(t *testing.T) {
+	in := writeTempFile(t, `@read1
+TCTCATCTGGTTAGATCGGAAGAGC
++
+IIIIIIIIIIIIIIIIIIIIIIIII
+@read2
+TCTCATCTGGTTCTGTCTCTTATAC
++
+IIIIIIIIIIIIIIIIIIIIIIIII
+@read3
+TCTCATCTGGTTTGGAATTCTCGG
++
+IIIIIIIIIIIIIIIIIIIIIIII
+`)
+	adapters := writeTempFile(t, ">nextera\nCTGTCTCTTATACACATCT\n")
+	want := "@read1\nTCTCATCTGGTT\n+\nIIIIIIIIIIII\n" +
+		"@read2\nTCTCATCTGGTT\n+\nIIIIIIIIIIII\n" +
+		"@read3\nTCTCATCTGGTTTGGAATTCTCGG\n+\n" +
+		"IIIIIIIIIIIIIIIIIIIIIIII\n"
+	out := writeTempFile(t, "")
+	os.Args = []string{"trimmer", "-l", "1", "-q", "0",
+		"-ae", "AGATCGGAAGAGC", "-afe", adapters, "-in", in, "-out", out}
+	main()
+
+	got, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Could not read output file: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Bad output. Expected:\n%s\nActual:\n%s", want, string(got))
+	}
+	if len(adapterEnd) != 2 {
+		t.Errorf("adapterEnd=%v, want 2 adapters", adapterEnd)
+	}
+}
+
+func Test_MainAutoDetect(t *testing.T) {
+	in := writeTempFile(t, `@read1
+TCTCATCTGGTTCTGTCTCTTATAC
++
+IIIIIIIIIIIIIIIIIIIIIIIII
+@read2
+TCTCATCTGGTTAGATCGGAAGAGA
++
+IIIIIIIIIIIIIIIIIIIIIIIII
+@read3
+ACGTACGTACGTACGTCTGTCTCTTATACACATCT
++
+IIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIII
+`)
+	want := "@read1\nTCTCATCTGGTT\n+\nIIIIIIIIIIII\n" +
+		"@read2\nTCTCATCTGGTTAGATCGGAAGAGA\n+\n" +
+		"IIIIIIIIIIIIIIIIIIIIIIIII\n" +
+		"@read3\nACGTACGTACGTACGT\n+\nIIIIIIIIIIIIIIII\n"
+	out := writeTempFile(t, "")
+	os.Args = []string{"trimmer", "-l", "1", "-q", "0", "-ae", "auto",
+		"-in", in, "-out", out}
+	main()
+
+	got, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Could not read output file: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Bad output. Expected:\n%s\nActual:\n%s", want, string(got))
+	}
+	if len(adapterEnd) != 1 || adapterEnd[0].Name != "Nextera" {
+		t.Errorf("adapterEnd=%v, want Nextera", adapterEnd)
+	}
+}
+
+func Test_DuplicateAdapters(t *testing.T) {
+	adapters := writeTempFile(t, ">a1\nAGATCGGAAGAGC\n>a1\nCTGTCTCTTATAC\n")
+	tests := [][]string{
+		{"trimmer", "-ae", "AGATCGGAAGAGC", "-ae", "AGATCGGAAGAGC"},
+		{"trimmer", "-afe", adapters},
+		{"trimmer", "-as", "ACGT", "-afs", writeTempFile(t, ">ACGT\nAC\n")},
+	}
+	for _, args := range tests {
+		os.Args = args
+		parseArguments()
+		if argumentError == nil {
+			t.Errorf("parseArguments(%v) succeeded, want error", args[1:])
+		}
+	}
+}
@@ -7,64 +7,74 @@ import (
 	"os"
 )
 
-var (
-	readCount         int   // How many reads in input file
-	shortCount        int   // How many reads were dropped for being too short
-	nucleotideCount   int   // How many nucleotides in input file
-	qualCount         int   // How many nucleotides were dropped for low quality
-	adapterStartCount []int // Histogram of trimmed adapter lengths
-	adapterEndCount   []int // Histogram of trimmed adapter lengths
-)
-
 // Nicely prints the run statistics.
 func printStatistics() {
+	stats := trimmer.Stats
+
 	// All reads count
-	fmt.Fprintln(os.Stderr, "Number of reads processed:", readCount)
-	fmt.Fprintln(os.Stderr, "Number of nucleotides in reads:", nucleotideCount)
+	fmt.Fprintln(os.Stderr, "Number of reads processed:", stats.Reads)
+	fmt.Fprintln(os.Stderr, "Number of nucleotides in reads:", stats.Bases)
 	fmt.Fprintf(os.Stderr,
 		"Number of reads dropped for being too short: %d (%.1f%%)\n",
-		shortCount, float64(shortCount)/float64(readCount)*100)
+		stats.Short, float64(stats.Short)/float64(stats.Reads)*100)
 
-	// Quality trimming count
-	if qualThreshold != 0 {
+	// Pair counts
+	if paired {
+		fmt.Fprintln(os.Stderr, "Number of pairs processed:", stats.Pairs)
 		fmt.Fprintf(os.Stderr,
-			"Number of low quality nucleotides trimmed: %d (%.1f%%)\n",
-			qualCount, float64(qualCount)/float64(nucleotideCount)*100)
-	}
-
-	// Shorten adapter count slices (up to last non-zero)
-	if len(adapterStartCount) > 0 {
-		trimAt := 0
-		for i, v := range adapterStartCount {
-			if v > 0 {
-				trimAt = i
-			}
+			"Number of pairs trimmed for adapter read-through: %d (%.1f%%)\n",
+			stats.ReadThrough,
+			float64(stats.ReadThrough)/float64(stats.Pairs)*100)
+		fmt.Fprintf(os.Stderr,
+			"Number of nucleotides trimmed for read-through: %d (%.1f%%)\n",
+			stats.ReadThroughBases,
+			float64(stats.ReadThroughBases)/float64(stats.Bases)*100)
+		orphans := "omitted"
+		if singleWriter != nil {
+			orphans = "written as singletons"
 		}
-		adapterStartCount = adapterStartCount[:trimAt+1]
+		fmt.Fprintf(os.Stderr,
+			"Number of pairs with one mate omitted: %d (%.1f%%, %s)\n",
+			stats.Orphans, float64(stats.Orphans)/float64(stats.Pairs)*100,
+			orphans)
 	}
 
-	if len(adapterEndCount) > 0 {
-		trimAt := 0
-		for i, v := range adapterEndCount {
-			if v > 0 {
-				trimAt = i
-			}
+	for _, step := range stats.Steps {
+		switch step.Name {
+		// Quality trimming count
+		case "quality":
+			fmt.Fprintf(os.Stderr,
+				"Number of low quality nucleotides trimmed: %d (%.1f%%)\n",
+				step.Bases, float64(step.Bases)/float64(stats.Bases)*100)
+
+		// Adapter trimming counts
+		case "adapter-start":
+			fmt.Fprintln(os.Stderr, "\n5' adapters trimmed:\nlength\tcount")
+			printLengths(step.Lengths)
+			printLabels(adapterStart, step.Labels)
+		case "adapter-end":
+			fmt.Fprintln(os.Stderr, "\n3' adapters trimmed:\nlength\tcount")
+			printLengths(step.Lengths)
+			printLabels(adapterEnd, step.Labels)
 		}
-		adapterEndCount = adapterEndCount[:trimAt+1]
 	}
+}
 
-	// Adapter trimming counts
-	if len(adapterStart) > 0 {
-		fmt.Fprintln(os.Stderr, "\n5' adapters trimmed:\nlength\tcount")
-		for i := 1; i < len(adapterStartCount); i++ {
-			fmt.Fprintf(os.Stderr, "%d\t%d\n", i, adapterStartCount[i])
-		}
+// Prints a histogram of trimmed lengths, skipping reads that were not
+// trimmed.
+func printLengths(lengths []int) {
+	for i := 1; i < len(lengths); i++ {
+		fmt.Fprintf(os.Stderr, "%d\t%d\n", i, lengths[i])
 	}
+}
 
-	if len(adapterEnd) > 0 {
-		fmt.Fprintln(os.Stderr, "\n3' adapters trimmed:\nlength\tcount")
-		for i := 1; i < len(adapterEndCount); i++ {
-			fmt.Fprintf(os.Stderr, "%d\t%d\n", i, adapterEndCount[i])
-		}
+// Prints the number of reads trimmed by each adapter, if there are several.
+func printLabels(adapters adapterList, labels map[string]int) {
+	if len(adapters) < 2 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "\nadapter\treads")
+	for _, a := range adapters {
+		fmt.Fprintf(os.Stderr, "%s\t%d\n", a.Name, labels[a.Name])
 	}
 }